@@ -2,9 +2,11 @@ package cli
 
 import (
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/kyawphyothu/sana/database"
@@ -15,54 +17,121 @@ import (
 // Run parses CLI args and runs the appropriate command if a subcommand is given.
 // Returns (true, exitCode) if a CLI command was run (caller should exit with exitCode),
 // or (false, 0) to run the TUI.
-func Run(db *sql.DB, args []string) (handled bool, exitCode int) {
+func Run(store database.Store, args []string) (handled bool, exitCode int) {
 	if len(args) < 2 {
 		return false, 0
 	}
 	sub := strings.TrimSpace(strings.ToLower(args[1]))
 	switch sub {
 	case "add":
-		return runAdd(db, args[2:])
+		return runAdd(store, args[2:])
 	case "delete", "del":
-		return runDelete(db, args[2:])
+		return runDelete(store, args[2:])
 	case "list", "ls":
-		return runList(db, args[2:])
+		return runList(store, args[2:])
+	case "migrate":
+		return runMigrate(store, args[2:])
+	case "merge":
+		return runMerge(store, args[2:])
 	default:
 		return false, 0
 	}
 }
 
-func runAdd(db *sql.DB, args []string) (handled bool, exitCode int) {
+func runAdd(store database.Store, args []string) (handled bool, exitCode int) {
 	fs := flag.NewFlagSet("add", flag.ExitOnError)
-	amountF := fs.Float64("amount", 0, "Expense amount (required)")
+	amountF := fs.String("amount", "", "Expense amount, or a calculation like 45.60/3 (required)")
 	descF := fs.String("description", "", "Expense description (required)")
-	typeF := fs.String("type", string(types.ExpenseTypeOther), "Category: food, transport, bills, shopping, health, personal_care, entertainment, education, other")
-	dateF := fs.String("date", "", "Date as YYYY-MM-DD or 'today' (default: today)")
+	typeF := fs.String("type", string(types.ExpenseTypeOther), "Category: food, transport, bills, shopping, health, personal_care, entertainment, education, other (default: the description's usual category, else other)")
+	dateF := fs.String("date", "", "Date as YYYY-MM-DD, MM-DD, a day of the month (15), yesterday, a weekday (mon, last fri), -2d or '3 days ago', optionally with HH:MM (default: today)")
+	fromF := fs.Int64("from-id", 0, "Copy amount, description and type from this expense; the other flags override them")
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: sana add -amount <n> -description <text> [-type <cat>] [-date YYYY-MM-DD]\n")
+		fmt.Fprintf(os.Stderr, "       sana add -from-id <expense_id> [-amount <n>] [-description <text>] [-type <cat>] [-date YYYY-MM-DD]\n")
+		fmt.Fprintf(os.Stderr, "       sana add <amount> <description> [@category] [date]   e.g. sana add 25.5 coffee @food yesterday\n")
 		fs.PrintDefaults()
 	}
 	if err := fs.Parse(args); err != nil {
 		return true, 1
 	}
-	desc := strings.TrimSpace(*descF)
-	if desc == "" {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if fs.NArg() > 0 {
+		if len(set) > 0 {
+			fmt.Fprintln(os.Stderr, "Error: give either flags or a quick entry, not both")
+			fs.Usage()
+			return true, 1
+		}
+		return runQuickAdd(store, strings.Join(fs.Args(), " "))
+	}
+
+	amountStr, desc, typeStr := *amountF, strings.TrimSpace(*descF), *typeF
+	if set["from-id"] {
+		if *fromF <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: -from-id must be a positive integer")
+			fs.Usage()
+			return true, 1
+		}
+		// The copy is dated today unless -date says otherwise
+		from, err := store.GetExpense(*fromF)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: expense id=%d: %v\n", *fromF, err)
+			return true, 1
+		}
+		if !set["amount"] {
+			amountStr = strconv.FormatFloat(from.Amount, 'f', -1, 64)
+		}
+		if !set["description"] {
+			desc = from.Description
+		}
+		if !set["type"] {
+			typeStr = string(from.Type)
+		}
+	} else if desc == "" {
 		fmt.Fprintln(os.Stderr, "Error: -description is required")
 		fs.Usage()
 		return true, 1
+	} else if !set["type"] {
+		// A description used before gets the category it usually has
+		inferred, ok, err := expense.InferType(store, desc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return true, 1
+		}
+		if ok {
+			typeStr = string(inferred)
+		}
 	}
 
-	id, err := expense.AddExpense(db, fmt.Sprint(*amountF), desc, *typeF, *dateF)
+	id, err := expense.AddExpense(store, amountStr, desc, typeStr, *dateF)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return true, 1
 	}
-	expType, _ := types.ParseExpenseType(*typeF)
-	fmt.Printf("Created expense id=%d (%.2f %s - %s)\n", id, *amountF, expType.String(), desc)
+	amount, _ := expense.EvalAmount(amountStr)
+	expType, _ := types.ParseExpenseType(typeStr)
+	fmt.Printf("Created expense id=%d (%.2f %s - %s)\n", id, amount, expType.String(), desc)
 	return true, 0
 }
 
-func runDelete(db *sql.DB, args []string) (handled bool, exitCode int) {
+// runQuickAdd adds the expense of a quick entry such as "25.5 coffee @food yesterday".
+func runQuickAdd(store database.Store, line string) (handled bool, exitCode int) {
+	q, err := expense.ParseQuickEntry(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return true, 1
+	}
+	id, expType, err := expense.AddQuickEntry(store, q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return true, 1
+	}
+	fmt.Printf("Created expense id=%d (%.2f %s - %s, %s)\n", id, q.Amount, expType.String(), q.Description, q.Date.Format("2006-01-02 15:04"))
+	return true, 0
+}
+
+func runDelete(store database.Store, args []string) (handled bool, exitCode int) {
 	fs := flag.NewFlagSet("delete", flag.ExitOnError)
 	idF := fs.Int64("id", 0, "Expense ID to delete (required)")
 	fs.Usage = func() {
@@ -77,7 +146,7 @@ func runDelete(db *sql.DB, args []string) (handled bool, exitCode int) {
 		fs.Usage()
 		return true, 1
 	}
-	err := database.DeleteExpense(db, *idF)
+	err := store.DeleteExpense(*idF)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error deleting expense: %v\n", err)
 		return true, 1
@@ -86,9 +155,9 @@ func runDelete(db *sql.DB, args []string) (handled bool, exitCode int) {
 	return true, 0
 }
 
-func runList(db *sql.DB, args []string) (handled bool, exitCode int) {
+func runList(store database.Store, args []string) (handled bool, exitCode int) {
 	fs := flag.NewFlagSet("list", flag.ExitOnError)
-	monthF := fs.String("month", "", "Month as YYYY-MM (default: current month)")
+	monthF := fs.String("month", "", "Month as YYYY-MM, a month name (mar), last or -N months back (default: current month)")
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: sana list [-month YYYY-MM]\n")
 		fs.PrintDefaults()
@@ -102,12 +171,12 @@ func runList(db *sql.DB, args []string) (handled bool, exitCode int) {
 		return true, 1
 	}
 
-	expenses, err := database.ListExpenses(db, month)
+	expenses, err := store.ListExpenses(month)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error listing expenses: %v\n", err)
 		return true, 1
 	}
-	total, err := database.GetTotalExpenses(db, month)
+	total, err := store.GetTotalExpenses(month)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error getting total: %v\n", err)
 		return true, 1
@@ -129,11 +198,117 @@ func runList(db *sql.DB, args []string) (handled bool, exitCode int) {
 	return true, 0
 }
 
+// ManagesMigrations reports whether args name a subcommand that manages the schema
+// itself, so the caller should not auto-migrate before running it.
+func ManagesMigrations(args []string) bool {
+	return len(args) >= 2 && strings.TrimSpace(strings.ToLower(args[1])) == "migrate"
+}
+
+func runMigrate(store database.Store, args []string) (handled bool, exitCode int) {
+	db, err := sqlDB(store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return true, 1
+	}
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	stepsF := fs.Int("steps", 1, "Number of migrations to revert (down only)")
+	forceF := fs.Bool("force", false, "Revert migrations even when that deletes data (down only)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: sana migrate status|up|down [-steps <n>] [-force]\n")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return true, 1
+	}
+	action := strings.TrimSpace(strings.ToLower(args[0]))
+	if err := fs.Parse(args[1:]); err != nil {
+		return true, 1
+	}
+
+	switch action {
+	case "status":
+		statuses, err := database.GetMigrationStatus(db)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading migrations: %v\n", err)
+			return true, 1
+		}
+		fmt.Printf("%-30s %-9s %s\n", "Migration", "Status", "Applied At")
+		fmt.Println(strings.Repeat("-", 60))
+		for _, s := range statuses {
+			status, appliedAt := "pending", ""
+			if s.Applied {
+				status = "applied"
+				appliedAt = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				status = "modified"
+			}
+			fmt.Printf("%-30s %-9s %s\n", s.Name, status, appliedAt)
+		}
+		return true, 0
+	case "up":
+		names, err := database.MigrateUp(db)
+		for _, name := range names {
+			fmt.Printf("Applied %s\n", name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running migrations: %v\n", err)
+			return true, 1
+		}
+		if len(names) == 0 {
+			fmt.Println("Already up to date")
+		}
+		return true, 0
+	case "down":
+		if *stepsF <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: -steps must be a positive integer")
+			fs.Usage()
+			return true, 1
+		}
+		names, err := database.MigrateDown(db, *stepsF, *forceF)
+		for _, name := range names {
+			fmt.Printf("Reverted %s\n", name)
+		}
+		if errors.Is(err, database.ErrMigrationDropsData) {
+			fmt.Fprintf(os.Stderr, "Error: %v\nBack up the database first, then run again with -force to revert it anyway\n", err)
+			return true, 1
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reverting migrations: %v\n", err)
+			return true, 1
+		}
+		if len(names) == 0 {
+			fmt.Println("Nothing to revert")
+		}
+		return true, 0
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown migrate action %q\n", action)
+		fs.Usage()
+		return true, 1
+	}
+}
+
 // PrintUsage prints a short usage line when the user passes an unknown subcommand or -h.
 func PrintUsage() {
-	fmt.Fprintf(os.Stderr, "Usage: sana [add|delete|list] [flags]\n")
+	fmt.Fprintf(os.Stderr, "Usage: sana [-profile <name>] [-passphrase-fd <n>] [add|delete|list|merge|migrate|profile|encrypt|decrypt] [flags]\n")
 	fmt.Fprintf(os.Stderr, "  add    -amount <n> -description <text> [-type <cat>] [-date YYYY-MM-DD]\n")
+	fmt.Fprintf(os.Stderr, "  add    -from-id <expense_id> [overriding flags]\n")
+	fmt.Fprintf(os.Stderr, "  add    <amount> <description> [@category] [date]\n")
 	fmt.Fprintf(os.Stderr, "  delete -id <expense_id>\n")
 	fmt.Fprintf(os.Stderr, "  list   [-month YYYY-MM]\n")
+	fmt.Fprintf(os.Stderr, "  merge  [-dry-run] [-both] OTHER.db\n")
+	fmt.Fprintf(os.Stderr, "  migrate status|up|down [-steps <n>] [-force]\n")
+	fmt.Fprintf(os.Stderr, "  profile list|create <name>|delete <name>|default <name>\n")
+	fmt.Fprintf(os.Stderr, "  encrypt|decrypt  encrypt or decrypt the profile's database (passphrase from -passphrase-fd, %s or a prompt)\n", PassphraseEnv)
 	fmt.Fprintf(os.Stderr, "Run with no arguments to start the TUI.\n")
 }
+
+// sqlDB returns the SQLite database behind store, for commands that only work on SQLite.
+func sqlDB(store database.Store) (*sql.DB, error) {
+	s, ok := store.(*database.SQLiteStore)
+	if !ok {
+		return nil, errors.New("this command needs a SQLite database")
+	}
+	return s.DB(), nil
+}
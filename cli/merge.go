@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kyawphyothu/sana/config"
+	"github.com/kyawphyothu/sana/database"
+)
+
+func runMerge(store database.Store, args []string) (handled bool, exitCode int) {
+	db, err := sqlDB(store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return true, 1
+	}
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	dryRunF := fs.Bool("dry-run", false, "Show what would change without writing anything")
+	bothF := fs.Bool("both", false, "Also merge this database into OTHER, so both end up the same")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: sana merge [-dry-run] [-both] OTHER.db\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return true, 1
+	}
+	// Allow flags after the path too ("sana merge other.db -dry-run")
+	rest := fs.Args()
+	if len(rest) > 1 {
+		if err := fs.Parse(rest[1:]); err != nil {
+			return true, 1
+		}
+		rest = append(rest[:1], fs.Args()...)
+	}
+	if len(rest) != 1 {
+		fs.Usage()
+		return true, 1
+	}
+	otherPath := rest[0]
+	if strings.HasSuffix(otherPath, config.EncryptedExt) {
+		fmt.Fprintln(os.Stderr, "Error: cannot merge an encrypted database; decrypt it first")
+		return true, 1
+	}
+	if _, err := os.Stat(otherPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return true, 1
+	}
+
+	// A dry run works on a copy so that not even migrations touch OTHER
+	openPath := otherPath
+	if *dryRunF {
+		tmp, remove, err := copyToTemp(otherPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error copying %s: %v\n", otherPath, err)
+			return true, 1
+		}
+		defer remove()
+		openPath = tmp
+	}
+	other, err := sql.Open("sqlite", openPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening %s: %v\n", otherPath, err)
+		return true, 1
+	}
+	defer other.Close()
+	if err := database.Migrate(other); err != nil {
+		fmt.Fprintf(os.Stderr, "Error migrating %s: %v\n", otherPath, err)
+		return true, 1
+	}
+
+	if *bothF {
+		res, back, err := database.MergeBoth(db, other, *dryRunF)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error merging: %v\n", err)
+			return true, 1
+		}
+		fmt.Printf("From %s into this database:\n", otherPath)
+		printMergeResult(res)
+		fmt.Printf("\nFrom this database into %s:\n", otherPath)
+		printMergeResult(back)
+		return mergeExit(res, *dryRunF)
+	}
+
+	res, err := database.Merge(db, other, *dryRunF)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error merging: %v\n", err)
+		return true, 1
+	}
+	fmt.Printf("From %s into this database:\n", otherPath)
+	printMergeResult(res)
+	return mergeExit(res, *dryRunF)
+}
+
+// mergeExit notes a dry run and returns the exit code of a merge with result res:
+// 3 when it had conflicts.
+func mergeExit(res *database.MergeResult, dryRun bool) (handled bool, exitCode int) {
+	if dryRun {
+		fmt.Println("\nDry run: nothing was changed.")
+	}
+	if len(res.Conflicts) > 0 {
+		return true, 3
+	}
+	return true, 0
+}
+
+func printMergeResult(res *database.MergeResult) {
+	fmt.Printf("  added:     %d\n", res.Added)
+	fmt.Printf("  updated:   %d\n", res.Updated)
+	fmt.Printf("  deleted:   %d\n", res.Deleted)
+	fmt.Printf("  unchanged: %d\n", res.Unchanged)
+	if len(res.Conflicts) == 0 {
+		return
+	}
+	fmt.Printf("  conflicts: %d\n", len(res.Conflicts))
+	for _, c := range res.Conflicts {
+		fmt.Printf("    %s  %-30s %s\n", c.UUID, truncate(c.Description, 30), c.Reason)
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
+
+// copyToTemp copies the SQLite database at path to a new temp file, with the
+// changes not yet checkpointed from its WAL and any hot journal, and returns its
+// path and a function that removes the copy.
+func copyToTemp(path string) (string, func(), error) {
+	dst, err := os.CreateTemp("", "sana-merge-*.db")
+	if err != nil {
+		return "", nil, err
+	}
+	dst.Close()
+	tmp := dst.Name()
+	remove := func() {
+		for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+			os.Remove(tmp + suffix)
+		}
+	}
+	// The -shm index is rebuilt from the WAL when the copy is opened
+	for _, suffix := range []string{"", "-wal", "-journal"} {
+		if err := copyFile(path+suffix, tmp+suffix); err != nil && (suffix == "" || !os.IsNotExist(err)) {
+			remove()
+			return "", nil, err
+		}
+	}
+	return tmp, remove, nil
+}
+
+// copyFile copies the file at src to dst, replacing it.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/kyawphyothu/sana/config"
+	"github.com/kyawphyothu/sana/database"
+)
+
+// PassphraseEnv is the environment variable holding the passphrase of an encrypted profile.
+const PassphraseEnv = "SANA_PASSPHRASE"
+
+// Passphrase returns the passphrase given with -passphrase-fd or SANA_PASSPHRASE.
+// ok is false if neither was given.
+func Passphrase(flags GlobalFlags) (passphrase string, ok bool, err error) {
+	if flags.PassphraseFD >= 0 {
+		f := os.NewFile(uintptr(flags.PassphraseFD), "passphrase")
+		if f == nil {
+			return "", false, fmt.Errorf("invalid passphrase file descriptor %d", flags.PassphraseFD)
+		}
+		defer f.Close()
+		line, err := bufio.NewReader(f).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", false, fmt.Errorf("reading passphrase: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), true, nil
+	}
+	if p, ok := os.LookupEnv(PassphraseEnv); ok {
+		return p, true, nil
+	}
+	return "", false, nil
+}
+
+// PromptPassphrase asks for a passphrase on the terminal without echoing it.
+func PromptPassphrase(prompt string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("no passphrase given: use -passphrase-fd or %s", PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// resolvePassphrase returns the passphrase from the flags or environment, prompting if neither is set.
+func resolvePassphrase(flags GlobalFlags) (string, error) {
+	p, ok, err := Passphrase(flags)
+	if err != nil || ok {
+		return p, err
+	}
+	return PromptPassphrase("Passphrase: ")
+}
+
+func runEncrypt(cfg *config.Config, flags GlobalFlags) (handled bool, exitCode int) {
+	if cfg.Encrypted {
+		fmt.Fprintf(os.Stderr, "Error: profile %s is already encrypted\n", cfg.Profile)
+		return true, 1
+	}
+	passphrase, err := newPassphrase(flags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return true, 1
+	}
+	if err := database.EncryptDatabase(cfg.DBPath, cfg.EncryptedDBPath, passphrase); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encrypting database: %v\n", err)
+		return true, 1
+	}
+	fmt.Printf("Encrypted profile %s\n", cfg.Profile)
+	return true, 0
+}
+
+func runDecrypt(cfg *config.Config, flags GlobalFlags) (handled bool, exitCode int) {
+	if !cfg.Encrypted {
+		fmt.Fprintf(os.Stderr, "Error: profile %s is not encrypted\n", cfg.Profile)
+		return true, 1
+	}
+	passphrase, err := resolvePassphrase(flags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return true, 1
+	}
+	if err := database.DecryptDatabase(cfg.EncryptedDBPath, cfg.DBPath, passphrase); err != nil {
+		fmt.Fprintf(os.Stderr, "Error decrypting database: %v\n", err)
+		return true, 1
+	}
+	fmt.Printf("Decrypted profile %s\n", cfg.Profile)
+	return true, 0
+}
+
+// newPassphrase gets a passphrase for encrypting, asking twice when prompting.
+func newPassphrase(flags GlobalFlags) (string, error) {
+	p, ok, err := Passphrase(flags)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		if p, err = PromptPassphrase("New passphrase: "); err != nil {
+			return "", err
+		}
+		confirm, err := PromptPassphrase("Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if p != confirm {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	if p == "" {
+		return "", errors.New("passphrase must not be empty")
+	}
+	return p, nil
+}
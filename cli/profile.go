@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kyawphyothu/sana/config"
+)
+
+// GlobalFlags are the flags given before the subcommand (e.g. "sana -profile work list").
+type GlobalFlags struct {
+	Profile      string // "" for the default profile
+	PassphraseFD int    // file descriptor to read the passphrase from, or -1
+}
+
+// ParseGlobalFlags parses the global flags and returns args with those flags removed.
+func ParseGlobalFlags(args []string) (flags GlobalFlags, rest []string, err error) {
+	flags.PassphraseFD = -1
+	if len(args) < 2 {
+		return flags, args, nil
+	}
+	fs := flag.NewFlagSet("sana", flag.ContinueOnError)
+	profileF := fs.String("profile", "", "Profile (ledger) to use (default: the default profile)")
+	fs.IntVar(&flags.PassphraseFD, "passphrase-fd", -1, "Read the passphrase of an encrypted profile from this file descriptor")
+	fs.Usage = PrintUsage
+	if err := fs.Parse(args[1:]); err != nil {
+		return flags, nil, err
+	}
+	flags.Profile = strings.TrimSpace(strings.ToLower(*profileF))
+	rest = append([]string{args[0]}, fs.Args()...)
+	return flags, rest, nil
+}
+
+// RunConfigCommand runs subcommands that work on configuration rather than an open database.
+// Returns (true, exitCode) if a command was run, or (false, 0) otherwise.
+func RunConfigCommand(cfg *config.Config, flags GlobalFlags, args []string) (handled bool, exitCode int) {
+	if len(args) < 2 {
+		return false, 0
+	}
+	sub := strings.TrimSpace(strings.ToLower(args[1]))
+	switch sub {
+	case "encrypt":
+		return runEncrypt(cfg, flags)
+	case "decrypt":
+		return runDecrypt(cfg, flags)
+	default:
+		return false, 0
+	}
+}
+
+// RunProfileCommand runs "sana profile ...". It runs before the profile's config is
+// loaded, so that a default profile whose database is gone can still be replaced.
+// Returns (true, exitCode) if it was a profile command, or (false, 0) otherwise.
+func RunProfileCommand(flags GlobalFlags, args []string) (handled bool, exitCode int) {
+	if len(args) < 2 {
+		return false, 0
+	}
+	switch strings.TrimSpace(strings.ToLower(args[1])) {
+	case "profile", "profiles":
+		return runProfile(flags, args[2:])
+	}
+	return false, 0
+}
+
+func runProfile(flags GlobalFlags, args []string) (handled bool, exitCode int) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: sana profile list|create <name>|delete <name>|default <name>\n")
+	}
+	if len(args) == 0 {
+		usage()
+		return true, 1
+	}
+	action := strings.TrimSpace(strings.ToLower(args[0]))
+	if action == "list" || action == "ls" {
+		return runProfileList(flags)
+	}
+
+	if len(args) != 2 {
+		usage()
+		return true, 1
+	}
+	name := strings.TrimSpace(strings.ToLower(args[1]))
+	var err error
+	var msg string
+	switch action {
+	case "create":
+		err = config.CreateProfile(name)
+		msg = fmt.Sprintf("Created profile %s", name)
+	case "delete", "del":
+		err = config.DeleteProfile(name)
+		msg = fmt.Sprintf("Deleted profile %s", name)
+	case "default":
+		err = config.SetDefaultProfile(name)
+		msg = fmt.Sprintf("Default profile is now %s", name)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown profile action %q\n", action)
+		usage()
+		return true, 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return true, 1
+	}
+	fmt.Println(msg)
+	return true, 0
+}
+
+func runProfileList(flags GlobalFlags) (handled bool, exitCode int) {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing profiles: %v\n", err)
+		return true, 1
+	}
+	settings, err := config.LoadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading settings: %v\n", err)
+		return true, 1
+	}
+	active, err := config.ActiveProfile(flags.Profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading settings: %v\n", err)
+		return true, 1
+	}
+	defaultProfile := settings.DefaultProfile
+	if defaultProfile == "" {
+		defaultProfile = config.DefaultProfile
+	}
+	for _, p := range profiles {
+		marker := " "
+		if p == active {
+			marker = "*"
+		}
+		note := ""
+		if p == defaultProfile {
+			note = " (default)"
+		}
+		fmt.Printf("%s %s%s\n", marker, p, note)
+	}
+	if !config.ProfileExists(defaultProfile) {
+		fmt.Fprintf(os.Stderr, "Warning: the default profile %q does not exist; choose another with: sana profile default <name>\n", defaultProfile)
+	}
+	return true, 0
+}
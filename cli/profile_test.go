@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyawphyothu/sana/config"
+)
+
+// TestProfileCommandsWithStaleDefault checks that the profile commands work when the
+// default profile in settings has lost its database, as they run before it is loaded.
+func TestProfileCommandsWithStaleDefault(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SANA_ENV", "")
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := config.CreateProfile("work"); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	if err := config.SetDefaultProfile("work"); err != nil {
+		t.Fatalf("SetDefaultProfile: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "sana", "profiles", "work.db")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"sana", "profile", "list"},
+		{"sana", "profile", "create", "work"},
+		{"sana", "profile", "default", "default"},
+	} {
+		handled, code := RunProfileCommand(GlobalFlags{PassphraseFD: -1}, args)
+		if !handled || code != 0 {
+			t.Errorf("RunProfileCommand(%v): got handled %v, exit %d; want handled, exit 0", args[1:], handled, code)
+		}
+	}
+	if cfg, err := config.LoadConfig(""); err != nil || cfg.Profile != config.DefaultProfile {
+		t.Errorf("LoadConfig after resetting the default: got %+v (%v), want the default profile", cfg, err)
+	}
+	if handled, _ := RunProfileCommand(GlobalFlags{PassphraseFD: -1}, []string{"sana", "list"}); handled {
+		t.Error("RunProfileCommand(list): handled, want it left to the other commands")
+	}
+}
@@ -1,28 +1,217 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 const dbFileName = "sana.db"
 
+// DefaultProfile is the profile used when none is given and no default is set.
+// It keeps using sana.db directly in the app dir so existing installs carry over.
+const DefaultProfile = "default"
+
+const profilesDirName = "profiles"
+
+// EncryptedExt is appended to a profile's database path when it is stored encrypted.
+const EncryptedExt = ".enc"
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
 type Config struct {
-	DBType string
-	DBName string
-	DBPath string
+	DBType  string
+	DBName  string
+	DBPath  string
+	Profile string
+
+	// Encrypted is true when the profile's database is stored encrypted at EncryptedDBPath.
+	Encrypted       bool
+	EncryptedDBPath string
 }
 
-func LoadConfig() (*Config, error) {
+// ActiveProfile returns the profile LoadConfig(profile) uses: profile itself, or
+// when empty the default profile from settings.
+func ActiveProfile(profile string) (string, error) {
+	if profile != "" {
+		return profile, nil
+	}
+	settings, err := LoadSettings()
+	if err != nil {
+		return "", err
+	}
+	if settings.DefaultProfile != "" {
+		return settings.DefaultProfile, nil
+	}
+	return DefaultProfile, nil
+}
+
+// LoadConfig returns the config for the named profile.
+// An empty profile uses the default profile from settings.
+func LoadConfig(profile string) (*Config, error) {
+	name, err := ActiveProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+	if !ProfileExists(name) {
+		if profile == "" {
+			return nil, fmt.Errorf("default profile %q does not exist (create it with: sana profile create %s, or choose another with: sana profile default <name>)", name, name)
+		}
+		return nil, fmt.Errorf("profile %q does not exist (create it with: sana profile create %s)", name, name)
+	}
+
 	cfg := &Config{
-		DBType: "sqlite",
-		DBName: dbFileName,
-		DBPath: getDBPath(),
+		DBType:          "sqlite",
+		DBName:          profileDBName(name),
+		DBPath:          profileDBPath(name),
+		Profile:         name,
+		EncryptedDBPath: profileDBPath(name) + EncryptedExt,
+	}
+	if fileExists(cfg.EncryptedDBPath) {
+		if fileExists(cfg.DBPath) {
+			return nil, fmt.Errorf("profile %q has both a plaintext and an encrypted database; remove one of %s and %s", name, cfg.DBPath, cfg.EncryptedDBPath)
+		}
+		cfg.Encrypted = true
 	}
 	return cfg, nil
 }
 
-func getDBPath() string {
+// ValidateProfileName checks that name is usable as a profile (and file) name.
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// ProfileExists reports whether the profile's database exists. The default profile always exists.
+func ProfileExists(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	path := profileDBPath(name)
+	return fileExists(path) || fileExists(path+EncryptedExt)
+}
+
+// ListProfiles returns all profile names, with the default profile first.
+func ListProfiles() ([]string, error) {
+	profiles := []string{DefaultProfile}
+	entries, err := os.ReadDir(filepath.Join(getAppDir(), profilesDirName))
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{DefaultProfile: true}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), EncryptedExt)
+		ext := filepath.Ext(name)
+		if entry.IsDir() || ext != ".db" {
+			continue
+		}
+		name = name[:len(name)-len(ext)]
+		if seen[name] || ValidateProfileName(name) != nil {
+			continue
+		}
+		seen[name] = true
+		profiles = append(profiles, name)
+	}
+	return profiles, nil
+}
+
+// CreateProfile creates an empty database for a new profile.
+// Migrations run the first time the profile is opened.
+func CreateProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if ProfileExists(name) {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	path := profileDBPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// DeleteProfile removes a profile's database. The default profile cannot be deleted,
+// and a profile that is set as the default must be unset first.
+func DeleteProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		return fmt.Errorf("the %q profile cannot be deleted", DefaultProfile)
+	}
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	settings, err := LoadSettings()
+	if err != nil {
+		return err
+	}
+	if settings.DefaultProfile == name {
+		return fmt.Errorf("profile %q is the default profile; set another default first", name)
+	}
+	path := profileDBPath(name)
+	// Remove SQLite side files and the encrypted copy too, if present
+	for _, suffix := range []string{"-wal", "-shm", "-journal", EncryptedExt} {
+		os.Remove(path + suffix)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// SetDefaultProfile stores name as the profile used when -profile is not given.
+func SetDefaultProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	settings, err := LoadSettings()
+	if err != nil {
+		return err
+	}
+	settings.DefaultProfile = name
+	return SaveSettings(settings)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func profileDBName(profile string) string {
+	if profile == DefaultProfile {
+		return dbFileName
+	}
+	return profile + ".db"
+}
+
+func profileDBPath(profile string) string {
+	if profile == DefaultProfile {
+		return filepath.Join(getAppDir(), dbFileName)
+	}
+	return filepath.Join(getAppDir(), profilesDirName, profileDBName(profile))
+}
+
+// getAppDir returns the directory holding Sana's databases and settings, creating it if needed.
+func getAppDir() string {
 	env := os.Getenv("SANA_ENV")
 	var configDir string
 	var err error
@@ -31,7 +220,7 @@ func getDBPath() string {
 	} else {
 		configDir, err = os.UserConfigDir()
 		if err != nil {
-			return filepath.Join(".", dbFileName)
+			return "."
 		}
 	}
 
@@ -41,5 +230,5 @@ func getDBPath() string {
 		os.MkdirAll(appDir, 0755)
 	}
 
-	return filepath.Join(appDir, dbFileName)
+	return appDir
 }
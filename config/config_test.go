@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// useTempAppDir points the app dir at a new temp dir for the test.
+func useTempAppDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("SANA_ENV", "")
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	return filepath.Join(dir, "sana")
+}
+
+func TestValidateProfileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"work", false},
+		{"side-gig_2", false},
+		{"2025", false},
+		{"default", false},
+		{"", true},
+		{"..", true},
+		{".", true},
+		{"a/b", true},
+		{"/", true},
+		{"-work", true},
+		{"_work", true},
+		{"Work", true},
+		{"my work", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateProfileName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateProfileName(%q): got %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCreateProfileInvalid(t *testing.T) {
+	useTempAppDir(t)
+	for _, name := range []string{"", "..", "/", "a/b", "../work", "default"} {
+		if err := CreateProfile(name); err == nil {
+			t.Errorf("CreateProfile(%q): got nil, want an error", name)
+		}
+	}
+	if err := DeleteProfile(DefaultProfile); err == nil {
+		t.Error("DeleteProfile(default): got nil, want an error")
+	}
+	if err := DeleteProfile("missing"); err == nil {
+		t.Error("DeleteProfile(missing): got nil, want an error")
+	}
+	if err := SetDefaultProfile("missing"); err == nil {
+		t.Error("SetDefaultProfile(missing): got nil, want an error")
+	}
+}
+
+func TestProfileRoundTrip(t *testing.T) {
+	appDir := useTempAppDir(t)
+
+	profiles, err := ListProfiles()
+	if err != nil || !slices.Equal(profiles, []string{DefaultProfile}) {
+		t.Fatalf("ListProfiles on a new install: got %v (%v), want [default]", profiles, err)
+	}
+
+	for _, name := range []string{"work", "home"} {
+		if err := CreateProfile(name); err != nil {
+			t.Fatalf("CreateProfile(%q): %v", name, err)
+		}
+	}
+	if err := CreateProfile("work"); err == nil {
+		t.Error("CreateProfile(work) again: got nil, want an error")
+	}
+	// Other files in the profiles dir are not profiles
+	if err := os.WriteFile(filepath.Join(appDir, profilesDirName, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err = ListProfiles()
+	if err != nil || !slices.Equal(profiles, []string{DefaultProfile, "home", "work"}) {
+		t.Errorf("ListProfiles: got %v (%v), want [default home work]", profiles, err)
+	}
+
+	cfg, err := LoadConfig("work")
+	if err != nil {
+		t.Fatalf("LoadConfig(work): %v", err)
+	}
+	if cfg.Profile != "work" || cfg.DBPath != filepath.Join(appDir, profilesDirName, "work.db") {
+		t.Errorf("LoadConfig(work): got profile %q at %s", cfg.Profile, cfg.DBPath)
+	}
+	if _, err := LoadConfig("missing"); err == nil {
+		t.Error("LoadConfig(missing): got nil, want an error")
+	}
+
+	// The default profile is used when none is given, and can't be deleted while set
+	if cfg, err := LoadConfig(""); err != nil || cfg.Profile != DefaultProfile {
+		t.Errorf("LoadConfig(\"\") before a default is set: got %+v (%v), want the default profile", cfg, err)
+	}
+	if err := SetDefaultProfile("work"); err != nil {
+		t.Fatalf("SetDefaultProfile(work): %v", err)
+	}
+	if cfg, err := LoadConfig(""); err != nil || cfg.Profile != "work" {
+		t.Errorf("LoadConfig(\"\") after SetDefaultProfile(work): got %+v (%v), want work", cfg, err)
+	}
+	if err := DeleteProfile("work"); err == nil {
+		t.Error("DeleteProfile of the default profile: got nil, want an error")
+	}
+	if err := SetDefaultProfile(DefaultProfile); err != nil {
+		t.Fatalf("SetDefaultProfile(default): %v", err)
+	}
+
+	// Deleting removes the database and its side files
+	path := filepath.Join(appDir, profilesDirName, "work.db")
+	if err := os.WriteFile(path+"-wal", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteProfile("work"); err != nil {
+		t.Fatalf("DeleteProfile(work): %v", err)
+	}
+	for _, p := range []string{path, path + "-wal"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("after DeleteProfile: %s still exists (%v)", filepath.Base(p), err)
+		}
+	}
+	profiles, err = ListProfiles()
+	if err != nil || !slices.Equal(profiles, []string{DefaultProfile, "home"}) {
+		t.Errorf("ListProfiles after delete: got %v (%v), want [default home]", profiles, err)
+	}
+	if ProfileExists("work") {
+		t.Error("ProfileExists(work) after delete: got true")
+	}
+}
+
+func TestStaleDefaultProfile(t *testing.T) {
+	appDir := useTempAppDir(t)
+	if err := CreateProfile("work"); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	if err := SetDefaultProfile("work"); err != nil {
+		t.Fatalf("SetDefaultProfile: %v", err)
+	}
+	if err := os.Remove(filepath.Join(appDir, profilesDirName, "work.db")); err != nil {
+		t.Fatal(err)
+	}
+
+	if name, err := ActiveProfile(""); err != nil || name != "work" {
+		t.Errorf("ActiveProfile(\"\"): got %q (%v), want work", name, err)
+	}
+	if _, err := LoadConfig(""); err == nil || !strings.Contains(err.Error(), "sana profile default") {
+		t.Errorf("LoadConfig(\"\"): got %v, want an error saying how to choose another default", err)
+	}
+	if cfg, err := LoadConfig(DefaultProfile); err != nil || cfg.Profile != DefaultProfile {
+		t.Errorf("LoadConfig(default): got %+v (%v), want the default profile", cfg, err)
+	}
+	if err := SetDefaultProfile(DefaultProfile); err != nil {
+		t.Fatalf("SetDefaultProfile(default): %v", err)
+	}
+	if cfg, err := LoadConfig(""); err != nil || cfg.Profile != DefaultProfile {
+		t.Errorf("LoadConfig(\"\") after resetting the default: got %+v (%v), want the default profile", cfg, err)
+	}
+}
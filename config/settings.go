@@ -0,0 +1,45 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const settingsFileName = "settings.json"
+
+// Settings holds user preferences shared by all profiles, stored as JSON in the app dir.
+type Settings struct {
+	DefaultProfile string `json:"default_profile,omitempty"`
+	// ExpenseSort is the TUI expenses box sort order, e.g. "amount desc" (empty: newest first).
+	ExpenseSort string `json:"expense_sort,omitempty"`
+}
+
+// LoadSettings reads the settings file. A missing file yields zero-value settings.
+func LoadSettings() (*Settings, error) {
+	settings := &Settings{}
+	data, err := os.ReadFile(settingsPath())
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveSettings writes settings to the settings file.
+func SaveSettings(settings *Settings) error {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(settingsPath(), append(data, '\n'), 0644)
+}
+
+func settingsPath() string {
+	return filepath.Join(getAppDir(), settingsFileName)
+}
@@ -0,0 +1,529 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kyawphyothu/sana/config"
+	"modernc.org/sqlite"
+	"modernc.org/sqlite/vfs"
+)
+
+// Encrypted database file layout:
+//
+//	magic (8) | PBKDF2 iterations (4, big endian) | salt (16) | nonce (12) | AES-256-GCM ciphertext
+//
+// The header is authenticated as GCM additional data. The plaintext is the SQLite database file.
+const (
+	encryptedMagic      = "SANAENC1"
+	encryptedSaltSize   = 16
+	encryptedNonceSize  = 12
+	encryptedKeySize    = 32
+	encryptedHeaderSize = len(encryptedMagic) + 4 + encryptedSaltSize
+	pbkdf2Iterations    = 600000
+)
+
+// ErrWrongPassphrase is returned when an encrypted database cannot be decrypted.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted database")
+
+// ErrVaultChanged is returned by a write to an encrypted database whose file another
+// process has replaced since it was read. The write is not saved; the next statement
+// sees the other process's data.
+var ErrVaultChanged = errors.New("the encrypted database was changed by another process; try again")
+
+// NewEncryptedDB opens the encrypted database at config.EncryptedDBPath.
+// The decrypted database lives only in memory; every committed write is
+// re-encrypted and written back to disk. Statements outside a transaction first
+// reload the file when another process has written it.
+func NewEncryptedDB(config *config.Config, passphrase string) (*sql.DB, error) {
+	v, err := openVault(config.EncryptedDBPath, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(&encryptedConnector{vault: v})
+	// One long-lived connection: the in-memory database exists per connection
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// EncryptDatabase encrypts the SQLite file at plainPath into encPath and removes plainPath.
+// A missing plainPath yields an encrypted empty database.
+func EncryptDatabase(plainPath, encPath, passphrase string) error {
+	if _, err := os.Stat(encPath); err == nil {
+		return fmt.Errorf("%s already exists", encPath)
+	}
+	plain, err := os.ReadFile(plainPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	v, err := newVault(encPath, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := v.save(plain); err != nil {
+		return err
+	}
+	removeSQLiteFiles(plainPath)
+	return nil
+}
+
+// DecryptDatabase decrypts the database at encPath into the SQLite file plainPath and removes encPath.
+func DecryptDatabase(encPath, plainPath, passphrase string) error {
+	if _, err := os.Stat(plainPath); err == nil {
+		return fmt.Errorf("%s already exists", plainPath)
+	}
+	v, err := openVault(encPath, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(plainPath, v.plaintext(), 0600); err != nil {
+		return err
+	}
+	return os.Remove(encPath)
+}
+
+// vault holds the key for one encrypted database file and its latest plaintext.
+type vault struct {
+	path       string
+	iterations uint32
+	salt       []byte
+	key        []byte
+
+	mu    sync.Mutex
+	plain []byte
+	// The file as last read or written (nil: not there yet), to notice other writers
+	file fs.FileInfo
+	// Times the file has been read again after another process wrote it
+	loads int64
+}
+
+// newVault prepares a vault for a new encrypted file with a fresh salt.
+func newVault(path, passphrase string) (*vault, error) {
+	salt := make([]byte, encryptedSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, encryptedKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return &vault{path: path, iterations: pbkdf2Iterations, salt: salt, key: key}, nil
+}
+
+// openVault reads and decrypts an existing encrypted file.
+func openVault(path, passphrase string) (*vault, error) {
+	data, file, err := readVaultFile(path)
+	if err != nil {
+		return nil, err
+	}
+	header := data[:encryptedHeaderSize]
+	iterations := binary.BigEndian.Uint32(header[len(encryptedMagic):])
+	salt := bytes.Clone(header[len(encryptedMagic)+4:])
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, int(iterations), encryptedKeySize)
+	if err != nil {
+		return nil, err
+	}
+	v := &vault{path: path, iterations: iterations, salt: salt, key: key, file: file}
+	if v.plain, err = v.decrypt(data); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// readVaultFile reads an encrypted file and checks its magic.
+func readVaultFile(path string) ([]byte, fs.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	// Stat the file read, not the path: a writer may rename another one into place
+	file, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) < encryptedHeaderSize+encryptedNonceSize || string(data[:len(encryptedMagic)]) != encryptedMagic {
+		return nil, nil, fmt.Errorf("%s is not an encrypted Sana database", path)
+	}
+	return data, file, nil
+}
+
+// decrypt decrypts the contents of an encrypted file with the vault's key.
+func (v *vault) decrypt(data []byte) ([]byte, error) {
+	header := data[:encryptedHeaderSize]
+	gcm, err := newGCM(v.key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := data[encryptedHeaderSize : encryptedHeaderSize+encryptedNonceSize]
+	plain, err := gcm.Open(nil, nonce, data[encryptedHeaderSize+encryptedNonceSize:], header)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plain, nil
+}
+
+// plaintext returns the latest saved database image.
+func (v *vault) plaintext() []byte {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.plain
+}
+
+// changedLocked reports whether the file is no longer the one last read or written.
+func (v *vault) changedLocked() (bool, error) {
+	file, err := os.Stat(v.path)
+	if os.IsNotExist(err) {
+		return v.file != nil, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return v.file == nil || !sameVaultFile(file, v.file), nil
+}
+
+// sameVaultFile reports whether a and b are the same version of a vault file. Saves
+// rename a new file into place, so a new version is a new file.
+func sameVaultFile(a, b fs.FileInfo) bool {
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+// reload reads the file again if another process has written it since, and reports
+// whether it did. A file encrypted with another passphrase since is an error.
+func (v *vault) reload() (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	changed, err := v.changedLocked()
+	if err != nil || !changed {
+		return false, err
+	}
+	data, file, err := readVaultFile(v.path)
+	if err != nil {
+		return false, err
+	}
+	header := data[:encryptedHeaderSize]
+	if binary.BigEndian.Uint32(header[len(encryptedMagic):]) != v.iterations || !bytes.Equal(header[len(encryptedMagic)+4:], v.salt) {
+		return false, fmt.Errorf("%s has been encrypted again since it was opened; open it again", v.path)
+	}
+	plain, err := v.decrypt(data)
+	if err != nil {
+		return false, err
+	}
+	v.plain, v.file = plain, file
+	v.loads++
+	return true, nil
+}
+
+// version returns how many times the file has been read again after another
+// process wrote it.
+func (v *vault) version() int64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.loads
+}
+
+// save encrypts plain with a fresh nonce and atomically replaces the vault file.
+// It returns ErrVaultChanged instead when another process has replaced the file
+// since it was last read or written.
+func (v *vault) save(plain []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if changed, err := v.changedLocked(); err != nil {
+		return err
+	} else if changed {
+		return ErrVaultChanged
+	}
+	gcm, err := newGCM(v.key)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 0, encryptedHeaderSize)
+	header = append(header, encryptedMagic...)
+	header = binary.BigEndian.AppendUint32(header, v.iterations)
+	header = append(header, v.salt...)
+	nonce := make([]byte, encryptedNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	out := append(bytes.Clone(header), nonce...)
+	out = gcm.Seal(out, nonce, plain, header)
+	if err := writeFileAtomic(v.path, out, 0600); err != nil {
+		return err
+	}
+	file, err := os.Stat(v.path)
+	if err != nil {
+		return err
+	}
+	v.plain, v.file = plain, file
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it into place.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeSQLiteFiles removes a SQLite database file and its side files.
+func removeSQLiteFiles(path string) {
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		os.Remove(path + suffix)
+	}
+}
+
+// encryptedConnector opens in-memory SQLite connections loaded from a vault.
+type encryptedConnector struct {
+	vault *vault
+}
+
+func (c *encryptedConnector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(":memory:")
+	if err != nil {
+		return nil, err
+	}
+	if plain := c.vault.plaintext(); len(plain) > 0 {
+		if err := restoreFromBytes(conn, plain); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return &encryptedConn{Conn: conn, vault: c.vault}, nil
+}
+
+func (c *encryptedConnector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// sqliteConn is the subset of modernc.org/sqlite connection methods used for encryption.
+type sqliteConn interface {
+	Serialize() ([]byte, error)
+	NewRestore(srcURI string) (*sqlite.Backup, error)
+}
+
+// restoreFromBytes loads a database image into conn through a read-only in-memory VFS,
+// so the plaintext never touches the disk. (The driver's Deserialize frees its buffer
+// with the wrong allocator on close, so it can't be used here.)
+func restoreFromBytes(conn driver.Conn, plain []byte) error {
+	name, fsys, err := vfs.New(imageFS{name: "sana.db", data: plain})
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+
+	bck, err := conn.(sqliteConn).NewRestore("file:sana.db?vfs=" + name)
+	if err != nil {
+		return err
+	}
+	if _, err := bck.Step(-1); err != nil {
+		bck.Finish()
+		return err
+	}
+	return bck.Finish()
+}
+
+// imageFS is a read-only file system holding one database image, for restoreFromBytes.
+type imageFS struct {
+	name string
+	data []byte
+}
+
+func (f imageFS) Open(name string) (fs.File, error) {
+	if name != f.name {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &imageFile{Reader: bytes.NewReader(f.data), name: name}, nil
+}
+
+// imageFile is the open image of an imageFS, and its own FileInfo. The VFS seeks
+// before every read.
+type imageFile struct {
+	*bytes.Reader
+	name string
+}
+
+func (f *imageFile) Stat() (fs.FileInfo, error) { return f, nil }
+func (f *imageFile) Close() error               { return nil }
+func (f *imageFile) Name() string               { return f.name }
+func (f *imageFile) Mode() fs.FileMode          { return 0400 }
+func (f *imageFile) ModTime() time.Time         { return time.Time{} }
+func (f *imageFile) IsDir() bool                { return false }
+func (f *imageFile) Sys() any                   { return nil }
+
+// encryptedConn writes the database back to its vault after every write
+// outside a transaction and after every commit that wrote, and reloads it before
+// a statement or transaction when another process has written the file.
+type encryptedConn struct {
+	driver.Conn
+	vault   *vault
+	inTx    bool
+	txDirty bool // the transaction has written
+	dirty   bool // written since the last save
+}
+
+// sync loads the vault file into the connection if another process has written it.
+// Unsaved writes (after ErrVaultChanged) give way to the file.
+func (c *encryptedConn) sync() error {
+	reloaded, err := c.vault.reload()
+	if err != nil || !reloaded {
+		return err
+	}
+	c.dirty = false
+	return restoreFromBytes(c.Conn, c.vault.plaintext())
+}
+
+// dataVersion returns the number of times another process's writes have been loaded,
+// loading them first.
+func (c *encryptedConn) dataVersion() (int64, error) {
+	if !c.inTx {
+		if err := c.sync(); err != nil {
+			return 0, err
+		}
+	}
+	return c.vault.version(), nil
+}
+
+func (c *encryptedConn) Prepare(query string) (driver.Stmt, error) {
+	if !c.inTx {
+		if err := c.sync(); err != nil {
+			return nil, err
+		}
+	}
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedStmt{Stmt: stmt, conn: c}, nil
+}
+
+func (c *encryptedConn) Begin() (driver.Tx, error) {
+	if err := c.sync(); err != nil {
+		return nil, err
+	}
+	tx, err := c.Conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	c.inTx, c.txDirty = true, false
+	return &encryptedTx{Tx: tx, conn: c}, nil
+}
+
+func (c *encryptedConn) Close() error {
+	err := c.persist()
+	if cerr := c.Conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// persist serializes the in-memory database and saves it to the vault, if it has
+// been written since the last save.
+func (c *encryptedConn) persist() error {
+	if !c.dirty {
+		return nil
+	}
+	plain, err := c.Conn.(sqliteConn).Serialize()
+	if err != nil {
+		return fmt.Errorf("serialize encrypted database: %w", err)
+	}
+	if err := c.vault.save(plain); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+type encryptedStmt struct {
+	driver.Stmt
+	conn *encryptedConn
+}
+
+func (s *encryptedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	res, err := s.Stmt.Exec(args)
+	if err != nil {
+		return res, err
+	}
+	if s.conn.inTx {
+		s.conn.txDirty = true
+		return res, nil
+	}
+	s.conn.dirty = true
+	return res, s.conn.persist()
+}
+
+type encryptedTx struct {
+	driver.Tx
+	conn *encryptedConn
+}
+
+func (t *encryptedTx) Commit() error {
+	t.conn.inTx = false
+	if err := t.Tx.Commit(); err != nil {
+		return err
+	}
+	t.conn.dirty = t.conn.dirty || t.conn.txDirty
+	return t.conn.persist()
+}
+
+func (t *encryptedTx) Rollback() error {
+	t.conn.inTx = false
+	return t.Tx.Rollback()
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kyawphyothu/sana/config"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestEncryptedDBRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "sana.db")
+	cfg := &config.Config{DBPath: plainPath, EncryptedDBPath: plainPath + config.EncryptedExt, Encrypted: true}
+
+	// Start from a plaintext database with one expense
+	plainDB, err := sql.Open("sqlite", plainPath)
+	if err != nil {
+		t.Fatalf("open plaintext db: %v", err)
+	}
+	if err := Migrate(plainDB); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	date := time.Date(2025, 3, 15, 10, 30, 0, 0, time.Local)
+	if _, err := CreateExpense(plainDB, date, 10, "secret lunch", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	plainDB.Close()
+
+	if err := EncryptDatabase(plainPath, cfg.EncryptedDBPath, "hunter2"); err != nil {
+		t.Fatalf("EncryptDatabase: %v", err)
+	}
+	if _, err := os.Stat(plainPath); !os.IsNotExist(err) {
+		t.Error("plaintext database should be removed after encrypting")
+	}
+	raw, err := os.ReadFile(cfg.EncryptedDBPath)
+	if err != nil {
+		t.Fatalf("read encrypted file: %v", err)
+	}
+	if bytes.Contains(raw, []byte("secret lunch")) {
+		t.Error("encrypted file should not contain plaintext data")
+	}
+
+	if _, err := NewEncryptedDB(cfg, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("NewEncryptedDB wrong passphrase: err = %v, want ErrWrongPassphrase", err)
+	}
+
+	// Writes through the encrypted DB are persisted without an explicit save
+	db, err := NewEncryptedDB(cfg, "hunter2")
+	if err != nil {
+		t.Fatalf("NewEncryptedDB: %v", err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migrate encrypted: %v", err)
+	}
+	if _, err := CreateExpense(db, date, 20, "dinner", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense encrypted: %v", err)
+	}
+	reopened, err := NewEncryptedDB(cfg, "hunter2")
+	if err != nil {
+		t.Fatalf("NewEncryptedDB reopen: %v", err)
+	}
+	list, err := ListExpenses(reopened, date)
+	if err != nil {
+		t.Fatalf("ListExpenses: %v", err)
+	}
+	if len(list) != 2 {
+		t.Errorf("reopened encrypted db: got %d expenses, want 2", len(list))
+	}
+	reopened.Close()
+	db.Close()
+
+	if err := DecryptDatabase(cfg.EncryptedDBPath, plainPath, "hunter2"); err != nil {
+		t.Fatalf("DecryptDatabase: %v", err)
+	}
+	plainDB, err = sql.Open("sqlite", plainPath)
+	if err != nil {
+		t.Fatalf("open decrypted db: %v", err)
+	}
+	defer plainDB.Close()
+	total, err := GetTotalExpenses(plainDB, date)
+	if err != nil {
+		t.Fatalf("GetTotalExpenses: %v", err)
+	}
+	if total != 30 {
+		t.Errorf("decrypted total = %.2f, want 30", total)
+	}
+}
+
+func TestEncryptDatabaseMissingPlaintext(t *testing.T) {
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "new.db")
+	cfg := &config.Config{DBPath: plainPath, EncryptedDBPath: plainPath + config.EncryptedExt, Encrypted: true}
+
+	if err := EncryptDatabase(plainPath, cfg.EncryptedDBPath, "pw"); err != nil {
+		t.Fatalf("EncryptDatabase: %v", err)
+	}
+	db, err := NewEncryptedDB(cfg, "pw")
+	if err != nil {
+		t.Fatalf("NewEncryptedDB: %v", err)
+	}
+	defer db.Close()
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migrate empty encrypted db: %v", err)
+	}
+}
+
+func TestEncryptedDBTwoWriters(t *testing.T) {
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "sana.db")
+	cfg := &config.Config{DBPath: plainPath, EncryptedDBPath: plainPath + config.EncryptedExt, Encrypted: true}
+	if err := EncryptDatabase(plainPath, cfg.EncryptedDBPath, "pw"); err != nil {
+		t.Fatalf("EncryptDatabase: %v", err)
+	}
+	open := func() *sql.DB {
+		db, err := NewEncryptedDB(cfg, "pw")
+		if err != nil {
+			t.Fatalf("NewEncryptedDB: %v", err)
+		}
+		return db
+	}
+	count := func(db *sql.DB) int {
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM expenses").Scan(&n); err != nil {
+			t.Fatalf("count expenses: %v", err)
+		}
+		return n
+	}
+	// The TUI and a `sana add` in another terminal
+	tui, cli := open(), open()
+	if err := Migrate(tui); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	date := time.Date(2025, 3, 15, 10, 30, 0, 0, time.Local)
+	for i, db := range []*sql.DB{tui, cli, tui} {
+		if _, err := CreateExpense(db, date, float64(i+1), "lunch", types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense %d: %v", i, err)
+		}
+	}
+	// Closing a handle that hasn't seen the last write leaves the file alone
+	if err := cli.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	fresh := open()
+	if got := count(fresh); got != 3 {
+		t.Errorf("after closing the other handle: got %d expenses, want 3", got)
+	}
+	fresh.Close()
+
+	// A transaction that started before another writer saved can't overwrite it
+	other := open()
+	tx, err := tui.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := CreateExpense(other, date, 10, "dinner", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense other: %v", err)
+	}
+	if _, err := tx.Exec("UPDATE expenses SET amount = 99"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrVaultChanged) {
+		t.Errorf("Commit after another writer: err = %v, want ErrVaultChanged", err)
+	}
+	other.Close()
+	if got := count(tui); got != 4 {
+		t.Errorf("after the refused commit: got %d expenses, want the other writer's 4", got)
+	}
+	tui.Close()
+
+	reopened := open()
+	defer reopened.Close()
+	var high int
+	if err := reopened.QueryRow("SELECT COUNT(*) FROM expenses WHERE amount = 99").Scan(&high); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if got := count(reopened); got != 4 || high != 0 {
+		t.Errorf("reopened: got %d expenses (%d updated), want 4 and the refused update not saved", got, high)
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/kyawphyothu/sana/types"
@@ -16,7 +17,7 @@ const DateTimeStorageFormat = "2006-01-02 15:04:05.999999"
 func ListExpenses(db *sql.DB, date time.Time) ([]types.Expense, error) {
 	dateStr := date.Format("2006-01-02")
 	rows, err := db.Query(`
-		SELECT id, date, amount, description, expense_type, created_at, updated_at
+		SELECT id, COALESCE(uuid, ''), date, amount, description, expense_type, created_at, updated_at
 		FROM expenses
 		WHERE strftime('%Y-%m', date) = strftime('%Y-%m', ?)
 		ORDER BY date DESC, id DESC
@@ -30,7 +31,7 @@ func ListExpenses(db *sql.DB, date time.Time) ([]types.Expense, error) {
 	for rows.Next() {
 		var e types.Expense
 		var typ string
-		if err := rows.Scan(&e.ID, &e.Date, &e.Amount, &e.Description, &typ, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		if err := rows.Scan(&e.ID, &e.UUID, &e.Date, &e.Amount, &e.Description, &typ, &e.CreatedAt, &e.UpdatedAt); err != nil {
 			return nil, err
 		}
 		e.Type = types.ExpenseType(typ)
@@ -106,6 +107,43 @@ func GetMonthlyReport(db *sql.DB) ([]types.MonthlyReport, error) {
 	return monthlyReport, rows.Err()
 }
 
+// GetDailyTotals returns the month's expenses grouped by day, oldest first.
+// Days without expenses are left out.
+func GetDailyTotals(db *sql.DB, date time.Time) ([]types.DailyTotal, error) {
+	dateStr := date.Format("2006-01-02")
+	rows, err := db.Query(`
+		SELECT strftime('%Y-%m-%d', date) as day, SUM(amount) as total, COUNT(*) as count
+		FROM expenses
+		WHERE strftime('%Y-%m', date) = strftime('%Y-%m', ?)
+		GROUP BY strftime('%Y-%m-%d', date)
+		ORDER BY day
+	`, dateStr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var daily []types.DailyTotal
+	for rows.Next() {
+		var dayStr string
+		var total float64
+		var count int
+		if err := rows.Scan(&dayStr, &total, &count); err != nil {
+			return nil, err
+		}
+		day, err := time.ParseInLocation("2006-01-02", dayStr, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		daily = append(daily, types.DailyTotal{
+			Day:   day,
+			Total: total,
+			Count: count,
+		})
+	}
+	return daily, rows.Err()
+}
+
 // GetTotalExpenses returns the sum of all expenses
 func GetTotalExpenses(db *sql.DB, date time.Time) (float64, error) {
 	dateStr := date.Format("2006-01-02")
@@ -114,21 +152,133 @@ func GetTotalExpenses(db *sql.DB, date time.Time) (float64, error) {
 	return total, err
 }
 
+// GetDescriptionHistory returns the descriptions used since the given date, most used
+// first (then most recent), with their most used category (the more recent one on a
+// tie) and last amount. At most limit are returned; limit <= 0 returns all.
+func GetDescriptionHistory(db *sql.DB, since time.Time, limit int) ([]types.DescriptionHistory, error) {
+	if limit <= 0 {
+		limit = -1 // no limit in SQLite
+	}
+	rows, err := db.Query(`
+		WITH recent AS (
+			SELECT id, date, amount, description, expense_type, lower(description) AS key
+			FROM expenses
+			WHERE description <> '' AND date >= ?
+		),
+		uses AS (
+			SELECT key, COUNT(*) AS count, MAX(date) AS last_used FROM recent GROUP BY key
+		),
+		latest AS (
+			SELECT key, description, amount,
+				ROW_NUMBER() OVER (PARTITION BY key ORDER BY date DESC, id DESC) AS n
+			FROM recent
+		),
+		usual AS (
+			SELECT key, expense_type,
+				ROW_NUMBER() OVER (PARTITION BY key ORDER BY COUNT(*) DESC, MAX(date) DESC) AS n
+			FROM recent
+			GROUP BY key, expense_type
+		)
+		SELECT latest.description, usual.expense_type, latest.amount, uses.count
+		FROM uses
+		JOIN latest ON latest.key = uses.key AND latest.n = 1
+		JOIN usual ON usual.key = uses.key AND usual.n = 1
+		ORDER BY uses.count DESC, uses.last_used DESC
+		LIMIT ?
+	`, since.Local().Format(DateTimeStorageFormat), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []types.DescriptionHistory
+	for rows.Next() {
+		var h types.DescriptionHistory
+		var typ string
+		if err := rows.Scan(&h.Description, &typ, &h.Amount, &h.Count); err != nil {
+			return nil, err
+		}
+		h.Type = types.ExpenseType(typ)
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// ErrExpenseNotFound is returned by GetExpense when no expense has the ID.
+var ErrExpenseNotFound = errors.New("expense not found")
+
+// GetExpense returns the expense with id, or ErrExpenseNotFound.
+func GetExpense(db *sql.DB, id int64) (types.Expense, error) {
+	var e types.Expense
+	var typ string
+	err := db.QueryRow(`
+		SELECT id, COALESCE(uuid, ''), date, amount, description, expense_type, created_at, updated_at
+		FROM expenses
+		WHERE id = ?
+	`, id).Scan(&e.ID, &e.UUID, &e.Date, &e.Amount, &e.Description, &typ, &e.CreatedAt, &e.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Expense{}, ErrExpenseNotFound
+	}
+	if err != nil {
+		return types.Expense{}, err
+	}
+	e.Type = types.ExpenseType(typ)
+	return e, nil
+}
+
 // CreateExpense inserts a new expense and returns the new ID.
 func CreateExpense(db *sql.DB, date time.Time, amount float64, description string, expenseType types.ExpenseType) (int64, error) {
 	dateStr := date.Local().Format(DateTimeStorageFormat)
+	uuid, err := newExpenseUUID()
+	if err != nil {
+		return 0, err
+	}
 	res, err := db.Exec(`
-		INSERT INTO expenses (date, amount, description, expense_type)
-		VALUES (?, ?, ?, ?)
-	`, dateStr, amount, description, string(expenseType))
+		INSERT INTO expenses (uuid, date, amount, description, expense_type)
+		VALUES (?, ?, ?, ?, ?)
+	`, uuid, dateStr, amount, description, string(expenseType))
 	if err != nil {
 		return 0, err
 	}
 	return res.LastInsertId()
 }
 
-// DeleteExpense removes an expense by ID.
+// DeleteExpense removes an expense by ID. Its UUID is remembered in deleted_expenses
+// so merging with another copy of the database doesn't bring it back.
 func DeleteExpense(db *sql.DB, id int64) error {
-	_, err := db.Exec(`DELETE FROM expenses WHERE id = ?`, id)
-	return err
+	return DeleteExpenses(db, []int64{id})
+}
+
+// DeleteExpenses removes expenses by ID in one transaction: either all are deleted or none.
+// Like DeleteExpense, their UUIDs are remembered in deleted_expenses.
+func DeleteExpenses(db *sql.DB, ids []int64) error {
+	return runInTx(db, func(tx *sql.Tx) error {
+		for _, id := range ids {
+			if _, err := tx.Exec(`
+				INSERT OR REPLACE INTO deleted_expenses (uuid, deleted_at)
+				SELECT uuid, CURRENT_TIMESTAMP FROM expenses WHERE id = ? AND uuid IS NOT NULL
+			`, id); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`DELETE FROM expenses WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateExpensesType changes the category of expenses by ID in one transaction,
+// bumping updated_at so the change wins when merging.
+func UpdateExpensesType(db *sql.DB, ids []int64, expenseType types.ExpenseType) error {
+	return runInTx(db, func(tx *sql.Tx) error {
+		for _, id := range ids {
+			if _, err := tx.Exec(`
+				UPDATE expenses SET expense_type = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+			`, string(expenseType), id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
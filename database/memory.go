@@ -0,0 +1,295 @@
+package database
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+// MemoryStore is a Store that keeps expenses in memory, for tests and previews.
+// It orders and aggregates the same way as the SQLite store.
+type MemoryStore struct {
+	mu       sync.Mutex
+	expenses []types.Expense
+	nextID   int64
+	version  int64 // counts changes, for DataVersion
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+// sameMonth reports whether a and b fall in the same local calendar month.
+func sameMonth(a, b time.Time) bool {
+	a, b = a.Local(), b.Local()
+	return a.Year() == b.Year() && a.Month() == b.Month()
+}
+
+func (s *MemoryStore) ListExpenses(month time.Time) ([]types.Expense, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []types.Expense
+	for _, e := range s.expenses {
+		if sameMonth(e.Date, month) {
+			list = append(list, e)
+		}
+	}
+	// Newest first, like the SQLite store
+	slices.SortFunc(list, func(a, b types.Expense) int {
+		if c := b.Date.Compare(a.Date); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return list, nil
+}
+
+func (s *MemoryStore) GetExpensesSummary(month time.Time) ([]types.CategorySummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byType := map[types.ExpenseType]*types.CategorySummary{}
+	var order []types.ExpenseType
+	for _, e := range s.expenses {
+		if !sameMonth(e.Date, month) {
+			continue
+		}
+		sum, ok := byType[e.Type]
+		if !ok {
+			sum = &types.CategorySummary{Category: e.Type.String()}
+			byType[e.Type] = sum
+			order = append(order, e.Type)
+		}
+		sum.Total += e.Amount
+		sum.Count++
+	}
+
+	var summaries []types.CategorySummary
+	for _, t := range order {
+		summaries = append(summaries, *byType[t])
+	}
+	slices.SortStableFunc(summaries, func(a, b types.CategorySummary) int {
+		if c := cmp.Compare(b.Total, a.Total); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Count, a.Count)
+	})
+	return summaries, nil
+}
+
+func (s *MemoryStore) GetMonthlyReport() ([]types.MonthlyReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totals := map[time.Time]float64{}
+	for _, e := range s.expenses {
+		d := e.Date.Local()
+		// Months are reported like the SQLite store: parsed from "YYYY-MM" in UTC
+		totals[time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)] += e.Amount
+	}
+
+	var report []types.MonthlyReport
+	for month, total := range totals {
+		report = append(report, types.MonthlyReport{Month: month, Total: total})
+	}
+	slices.SortFunc(report, func(a, b types.MonthlyReport) int {
+		return b.Month.Compare(a.Month)
+	})
+	return report, nil
+}
+
+func (s *MemoryStore) GetDailyTotals(month time.Time) ([]types.DailyTotal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byDay := map[time.Time]*types.DailyTotal{}
+	var daily []*types.DailyTotal
+	for _, e := range s.expenses {
+		if !sameMonth(e.Date, month) {
+			continue
+		}
+		d := e.Date.Local()
+		day := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.Local)
+		dt, ok := byDay[day]
+		if !ok {
+			dt = &types.DailyTotal{Day: day}
+			byDay[day] = dt
+			daily = append(daily, dt)
+		}
+		dt.Total += e.Amount
+		dt.Count++
+	}
+
+	// Oldest first, like the SQLite store
+	slices.SortFunc(daily, func(a, b *types.DailyTotal) int {
+		return a.Day.Compare(b.Day)
+	})
+	var list []types.DailyTotal
+	for _, dt := range daily {
+		list = append(list, *dt)
+	}
+	return list, nil
+}
+
+func (s *MemoryStore) GetTotalExpenses(month time.Time) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total float64
+	for _, e := range s.expenses {
+		if sameMonth(e.Date, month) {
+			total += e.Amount
+		}
+	}
+	return total, nil
+}
+
+func (s *MemoryStore) GetDescriptionHistory(since time.Time, limit int) ([]types.DescriptionHistory, error) {
+	s.mu.Lock()
+	expenses := slices.Clone(s.expenses)
+	s.mu.Unlock()
+
+	// Newest first, so the first expense of a description is its last use
+	slices.SortFunc(expenses, func(a, b types.Expense) int {
+		if c := b.Date.Compare(a.Date); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	type uses struct {
+		history  types.DescriptionHistory
+		lastUsed time.Time
+		byType   map[types.ExpenseType]int
+		order    []types.ExpenseType // most recently used first
+	}
+	byKey := map[string]*uses{}
+	var all []*uses
+	for _, e := range expenses {
+		if e.Description == "" || e.Date.Before(since) {
+			continue
+		}
+		key := strings.ToLower(e.Description)
+		u, ok := byKey[key]
+		if !ok {
+			u = &uses{
+				history:  types.DescriptionHistory{Description: e.Description, Amount: e.Amount},
+				lastUsed: e.Date,
+				byType:   map[types.ExpenseType]int{},
+			}
+			byKey[key] = u
+			all = append(all, u)
+		}
+		u.history.Count++
+		if u.byType[e.Type] == 0 {
+			u.order = append(u.order, e.Type)
+		}
+		u.byType[e.Type]++
+	}
+
+	slices.SortStableFunc(all, func(a, b *uses) int {
+		if c := cmp.Compare(b.history.Count, a.history.Count); c != 0 {
+			return c
+		}
+		return b.lastUsed.Compare(a.lastUsed)
+	})
+	var history []types.DescriptionHistory
+	for _, u := range all {
+		if limit > 0 && len(history) == limit {
+			break
+		}
+		for _, t := range u.order {
+			if u.byType[t] > u.byType[u.history.Type] {
+				u.history.Type = t
+			}
+		}
+		history = append(history, u.history)
+	}
+	return history, nil
+}
+
+func (s *MemoryStore) GetExpense(id int64) (types.Expense, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.expenses, func(e types.Expense) bool { return e.ID == id })
+	if i < 0 {
+		return types.Expense{}, ErrExpenseNotFound
+	}
+	return s.expenses[i], nil
+}
+
+func (s *MemoryStore) CreateExpense(date time.Time, amount float64, description string, expenseType types.ExpenseType) (int64, error) {
+	uuid, err := newExpenseUUID()
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	e := types.Expense{
+		ID:          s.nextID,
+		UUID:        uuid,
+		Date:        date.Local(),
+		Amount:      amount,
+		Description: description,
+		Type:        expenseType,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	s.nextID++
+	s.expenses = append(s.expenses, e)
+	s.version++
+	return e.ID, nil
+}
+
+func (s *MemoryStore) DeleteExpense(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expenses = slices.DeleteFunc(s.expenses, func(e types.Expense) bool { return e.ID == id })
+	s.version++
+	return nil
+}
+
+func (s *MemoryStore) DeleteExpenses(ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expenses = slices.DeleteFunc(s.expenses, func(e types.Expense) bool { return slices.Contains(ids, e.ID) })
+	s.version++
+	return nil
+}
+
+func (s *MemoryStore) UpdateExpensesType(ids []int64, expenseType types.ExpenseType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for i := range s.expenses {
+		if slices.Contains(ids, s.expenses[i].ID) {
+			s.expenses[i].Type = expenseType
+			s.expenses[i].UpdatedAt = now
+		}
+	}
+	s.version++
+	return nil
+}
+
+// DataVersion returns the number of changes made to the store.
+func (s *MemoryStore) DataVersion() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.version, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,261 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// MergeResult summarizes what Merge changed (or would change, for a dry run).
+type MergeResult struct {
+	Added     int // expenses only in the other database
+	Updated   int // expenses changed more recently in the other database
+	Deleted   int // expenses deleted in the other database
+	Unchanged int
+	Conflicts []MergeConflict
+}
+
+// MergeConflict is an expense changed on both sides where the newer version couldn't
+// safely be picked. Description is taken from the version that was kept.
+type MergeConflict struct {
+	UUID        string
+	Description string
+	Reason      string
+}
+
+// syncExpense is an expense row as stored, compared field by field when merging.
+type syncExpense struct {
+	id          int64
+	uuid        string
+	date        string
+	amount      float64
+	description string
+	typ         string
+	createdAt   string
+	updatedAt   string
+}
+
+// sameContent reports whether two versions of an expense hold the same data.
+func (e syncExpense) sameContent(o syncExpense) bool {
+	return e.date == o.date && e.amount == o.amount && e.description == o.description && e.typ == o.typ
+}
+
+// syncState is everything Merge needs from one database: its expenses and deletions by UUID.
+type syncState struct {
+	expenses map[string]syncExpense
+	deleted  map[string]string // uuid -> deleted_at
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// Merge brings the changes in other into db, matching expenses by UUID:
+// new expenses are added, edited ones take the version with the later updated_at,
+// and expenses deleted on one side are deleted on the other unless edited afterwards.
+// Both databases must be migrated. With dryRun, db is left untouched.
+func Merge(db, other *sql.DB, dryRun bool) (*MergeResult, error) {
+	theirs, err := readSyncState(other)
+	if err != nil {
+		return nil, fmt.Errorf("read other database: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := mergeInto(tx, theirs)
+	if err != nil || dryRun {
+		return res, err
+	}
+	return res, tx.Commit()
+}
+
+// MergeBoth merges other into db, then db (with the changes from other) back into
+// other, so both end up the same; it returns the results of the two merges. Both
+// merges run in transactions committed at the end, so with dryRun neither database
+// is changed and the second result is still what a real run would do.
+func MergeBoth(db, other *sql.DB, dryRun bool) (forward, back *MergeResult, err error) {
+	otherTx, err := other.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer otherTx.Rollback()
+	theirs, err := readSyncState(otherTx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read other database: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+	if forward, err = mergeInto(tx, theirs); err != nil {
+		return nil, nil, err
+	}
+	ours, err := readSyncState(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if back, err = mergeInto(otherTx, ours); err != nil {
+		return nil, nil, fmt.Errorf("merge back into other database: %w", err)
+	}
+
+	if dryRun {
+		return forward, back, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	if err := otherTx.Commit(); err != nil {
+		return forward, nil, fmt.Errorf("merge back into other database: %w", err)
+	}
+	return forward, back, nil
+}
+
+// mergeInto applies theirs to the database of tx and returns what changed.
+func mergeInto(tx *sql.Tx, theirs *syncState) (*MergeResult, error) {
+	ours, err := readSyncState(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sorted so merges apply (and report conflicts) deterministically
+	res := &MergeResult{}
+	for _, uuid := range slices.Sorted(maps.Keys(theirs.expenses)) {
+		their := theirs.expenses[uuid]
+		our, exists := ours.expenses[uuid]
+		switch {
+		case exists && our.sameContent(their):
+			res.Unchanged++
+		case exists && their.updatedAt > our.updatedAt:
+			if _, err := tx.Exec(`
+				UPDATE expenses SET date = ?, amount = ?, description = ?, expense_type = ?, updated_at = ?
+				WHERE id = ?
+			`, their.date, their.amount, their.description, their.typ, their.updatedAt, our.id); err != nil {
+				return nil, err
+			}
+			res.Updated++
+		case exists && their.updatedAt == our.updatedAt:
+			res.Conflicts = append(res.Conflicts, MergeConflict{UUID: uuid, Description: our.description,
+				Reason: "changed on both sides at the same time; kept this version"})
+		case exists:
+			res.Unchanged++ // ours is newer
+		default:
+			deletedAt, wasDeleted := ours.deleted[uuid]
+			if wasDeleted && deletedAt >= their.updatedAt {
+				res.Unchanged++ // deleted here after the other side last changed it
+				continue
+			}
+			if wasDeleted {
+				res.Conflicts = append(res.Conflicts, MergeConflict{UUID: uuid, Description: their.description,
+					Reason: "deleted here but edited later on the other side; restored"})
+				if _, err := tx.Exec(`DELETE FROM deleted_expenses WHERE uuid = ?`, uuid); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := tx.Exec(`
+				INSERT INTO expenses (uuid, date, amount, description, expense_type, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+			`, uuid, their.date, their.amount, their.description, their.typ, their.createdAt, their.updatedAt); err != nil {
+				return nil, err
+			}
+			res.Added++
+		}
+	}
+
+	for _, uuid := range slices.Sorted(maps.Keys(theirs.deleted)) {
+		deletedAt := theirs.deleted[uuid]
+		if our, exists := ours.expenses[uuid]; exists {
+			if our.updatedAt > deletedAt {
+				res.Conflicts = append(res.Conflicts, MergeConflict{UUID: uuid, Description: our.description,
+					Reason: "deleted on the other side but edited later here; kept"})
+				continue
+			}
+			if _, err := tx.Exec(`DELETE FROM expenses WHERE id = ?`, our.id); err != nil {
+				return nil, err
+			}
+			res.Deleted++
+		}
+		// Remember the deletion so it reaches databases merged from this one
+		if _, err := tx.Exec(`
+			INSERT INTO deleted_expenses (uuid, deleted_at) VALUES (?, ?)
+			ON CONFLICT(uuid) DO UPDATE SET deleted_at = MAX(deleted_at, excluded.deleted_at)
+		`, uuid, deletedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func readSyncState(q queryer) (*syncState, error) {
+	state := &syncState{expenses: map[string]syncExpense{}, deleted: map[string]string{}}
+
+	rows, err := q.Query(`
+		SELECT id, uuid, CAST(date AS TEXT), amount, COALESCE(description, ''), expense_type,
+			COALESCE(CAST(created_at AS TEXT), ''), COALESCE(CAST(updated_at AS TEXT), '')
+		FROM expenses WHERE uuid IS NOT NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e syncExpense
+		if err := rows.Scan(&e.id, &e.uuid, &e.date, &e.amount, &e.description, &e.typ, &e.createdAt, &e.updatedAt); err != nil {
+			return nil, err
+		}
+		state.expenses[e.uuid] = e
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	delRows, err := q.Query(`SELECT uuid, CAST(deleted_at AS TEXT) FROM deleted_expenses`)
+	if err != nil {
+		return nil, err
+	}
+	defer delRows.Close()
+	for delRows.Next() {
+		var uuid, deletedAt string
+		if err := delRows.Scan(&uuid, &deletedAt); err != nil {
+			return nil, err
+		}
+		state.deleted[uuid] = deletedAt
+	}
+	return state, delRows.Err()
+}
+
+// newExpenseUUID returns a random (version 4) UUID for a new expense.
+func newExpenseUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b), nil
+}
+
+// legacyExpenseUUID derives a UUID for an expense created before UUIDs existed.
+// It depends only on the row, so every copy of the database derives the same one.
+func legacyExpenseUUID(id int64, date string, amount float64, description, typ, createdAt string) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d\x00%s\x00%v\x00%s\x00%s\x00%s", id, date, amount, description, typ, createdAt))
+	var b [16]byte
+	copy(b[:], sum[:16])
+	b[6] = b[6]&0x0f | 0x80 // version 8: custom
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b)
+}
+
+func formatUUID(b [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+var mergeDate = time.Date(2025, 3, 15, 10, 30, 0, 0, time.Local)
+
+// mergeFixture creates an expense with a fixed updated_at and returns its UUID.
+func mergeFixture(t *testing.T, db *sql.DB, amount float64, desc, updatedAt string) string {
+	t.Helper()
+	id, err := CreateExpense(db, mergeDate, amount, desc, types.ExpenseTypeFood)
+	if err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	if _, err := db.Exec("UPDATE expenses SET updated_at = ? WHERE id = ?", updatedAt, id); err != nil {
+		t.Fatalf("set updated_at: %v", err)
+	}
+	var uuid string
+	if err := db.QueryRow("SELECT uuid FROM expenses WHERE id = ?", id).Scan(&uuid); err != nil {
+		t.Fatalf("read uuid: %v", err)
+	}
+	return uuid
+}
+
+// copyExpense inserts the expense with uuid from src into dst unchanged, as if both databases
+// were copies of the same file.
+func copyExpense(t *testing.T, src, dst *sql.DB, uuid string) {
+	t.Helper()
+	var date, desc, typ, createdAt, updatedAt string
+	var amount float64
+	err := src.QueryRow(`SELECT CAST(date AS TEXT), amount, description, expense_type, CAST(created_at AS TEXT), CAST(updated_at AS TEXT)
+		FROM expenses WHERE uuid = ?`, uuid).Scan(&date, &amount, &desc, &typ, &createdAt, &updatedAt)
+	if err != nil {
+		t.Fatalf("read expense %s: %v", uuid, err)
+	}
+	if _, err := dst.Exec(`INSERT INTO expenses (uuid, date, amount, description, expense_type, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, uuid, date, amount, desc, typ, createdAt, updatedAt); err != nil {
+		t.Fatalf("copy expense %s: %v", uuid, err)
+	}
+}
+
+func expenseAmounts(t *testing.T, db *sql.DB) map[string]float64 {
+	t.Helper()
+	list, err := ListExpenses(db, mergeDate)
+	if err != nil {
+		t.Fatalf("ListExpenses: %v", err)
+	}
+	amounts := make(map[string]float64)
+	for _, e := range list {
+		amounts[e.Description] = e.Amount
+	}
+	return amounts
+}
+
+func TestMerge(t *testing.T) {
+	ours := testDB(t)
+	defer ours.Close()
+	theirs := testDB(t)
+	defer theirs.Close()
+
+	// Shared expenses, then changes made separately on each side
+	edited := mergeFixture(t, ours, 10, "edited there", "2025-03-15 10:00:00")
+	copyExpense(t, ours, theirs, edited)
+	if _, err := theirs.Exec("UPDATE expenses SET amount = 12, updated_at = '2025-03-16 09:00:00' WHERE uuid = ?", edited); err != nil {
+		t.Fatal(err)
+	}
+	keptHere := mergeFixture(t, ours, 20, "edited here", "2025-03-17 10:00:00")
+	copyExpense(t, ours, theirs, keptHere)
+	if _, err := theirs.Exec("UPDATE expenses SET amount = 21, updated_at = '2025-03-16 10:00:00' WHERE uuid = ?", keptHere); err != nil {
+		t.Fatal(err)
+	}
+	deletedThere := mergeFixture(t, ours, 30, "deleted there", "2025-03-15 10:00:00")
+	copyExpense(t, ours, theirs, deletedThere)
+	if _, err := theirs.Exec("INSERT INTO deleted_expenses (uuid, deleted_at) VALUES (?, '2025-03-16 10:00:00')", deletedThere); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := theirs.Exec("DELETE FROM expenses WHERE uuid = ?", deletedThere); err != nil {
+		t.Fatal(err)
+	}
+	deletedHere := mergeFixture(t, theirs, 40, "deleted here", "2025-03-15 10:00:00")
+	if _, err := ours.Exec("INSERT INTO deleted_expenses (uuid, deleted_at) VALUES (?, '2025-03-16 10:00:00')", deletedHere); err != nil {
+		t.Fatal(err)
+	}
+	mergeFixture(t, theirs, 50, "new there", "2025-03-16 10:00:00")
+	mergeFixture(t, ours, 60, "new here", "2025-03-16 10:00:00")
+
+	// A dry run reports the same but changes nothing
+	before := expenseAmounts(t, ours)
+	dry, err := Merge(ours, theirs, true)
+	if err != nil {
+		t.Fatalf("Merge dry run: %v", err)
+	}
+	if got := expenseAmounts(t, ours); len(got) != len(before) || got["edited there"] != 10 {
+		t.Errorf("dry run changed the database: got %v, want %v", got, before)
+	}
+
+	res, err := Merge(ours, theirs, false)
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if res.Added != dry.Added || res.Updated != dry.Updated || res.Deleted != dry.Deleted {
+		t.Errorf("dry run result %+v differs from merge result %+v", dry, res)
+	}
+	if res.Added != 1 || res.Updated != 1 || res.Deleted != 1 || len(res.Conflicts) != 0 {
+		t.Errorf("Merge result: got %+v, want 1 added, 1 updated, 1 deleted, no conflicts", res)
+	}
+
+	want := map[string]float64{"edited there": 12, "edited here": 20, "new there": 50, "new here": 60}
+	got := expenseAmounts(t, ours)
+	if len(got) != len(want) {
+		t.Errorf("after merge: got %v, want %v", got, want)
+	}
+	for desc, amount := range want {
+		if got[desc] != amount {
+			t.Errorf("after merge %q: got %.2f, want %.2f", desc, got[desc], amount)
+		}
+	}
+
+	// Merging again is a no-op
+	again, err := Merge(ours, theirs, false)
+	if err != nil {
+		t.Fatalf("Merge again: %v", err)
+	}
+	if again.Added != 0 || again.Updated != 0 || again.Deleted != 0 {
+		t.Errorf("second merge: got %+v, want no changes", again)
+	}
+}
+
+func TestMergeBoth(t *testing.T) {
+	ours := testDB(t)
+	defer ours.Close()
+	theirs := testDB(t)
+	defer theirs.Close()
+
+	shared := mergeFixture(t, ours, 10, "shared", "2025-03-15 10:00:00")
+	copyExpense(t, ours, theirs, shared)
+	mergeFixture(t, ours, 20, "new here", "2025-03-16 10:00:00")
+	mergeFixture(t, theirs, 30, "new there", "2025-03-16 10:00:00")
+
+	// The dry run's merge back sees what the first merge would add
+	dryForward, dryBack, err := MergeBoth(ours, theirs, true)
+	if err != nil {
+		t.Fatalf("MergeBoth dry run: %v", err)
+	}
+	if len(expenseAmounts(t, ours)) != 2 || len(expenseAmounts(t, theirs)) != 2 {
+		t.Errorf("dry run changed a database: got %v and %v", expenseAmounts(t, ours), expenseAmounts(t, theirs))
+	}
+
+	forward, back, err := MergeBoth(ours, theirs, false)
+	if err != nil {
+		t.Fatalf("MergeBoth: %v", err)
+	}
+	if !reflect.DeepEqual(dryForward, forward) || !reflect.DeepEqual(dryBack, back) {
+		t.Errorf("dry run results %+v, %+v differ from merge results %+v, %+v", dryForward, dryBack, forward, back)
+	}
+	if forward.Added != 1 || forward.Unchanged != 1 || back.Added != 1 || back.Unchanged != 2 {
+		t.Errorf("MergeBoth: got %+v and %+v, want 1 added each way and the rest unchanged", forward, back)
+	}
+	if got := expenseAmounts(t, theirs); len(got) != 3 || got["new here"] != 20 {
+		t.Errorf("other database after MergeBoth: got %v, want all 3 expenses", got)
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	ours := testDB(t)
+	defer ours.Close()
+	theirs := testDB(t)
+	defer theirs.Close()
+
+	// Edited on both sides with the same timestamp
+	same := mergeFixture(t, ours, 10, "same time", "2025-03-16 10:00:00")
+	copyExpense(t, ours, theirs, same)
+	if _, err := theirs.Exec("UPDATE expenses SET amount = 11 WHERE uuid = ?", same); err != nil {
+		t.Fatal(err)
+	}
+	// Deleted there, but edited here afterwards
+	editedAfterDelete := mergeFixture(t, ours, 20, "edited after delete", "2025-03-17 10:00:00")
+	if _, err := theirs.Exec("INSERT INTO deleted_expenses (uuid, deleted_at) VALUES (?, '2025-03-16 10:00:00')", editedAfterDelete); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Merge(ours, theirs, false)
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if len(res.Conflicts) != 2 {
+		t.Fatalf("Merge conflicts: got %+v, want 2", res.Conflicts)
+	}
+	got := expenseAmounts(t, ours)
+	if got["same time"] != 10 || got["edited after delete"] != 20 {
+		t.Errorf("conflicting expenses should keep this version: got %v", got)
+	}
+}
+
+func TestDeleteExpenseRecordsTombstone(t *testing.T) {
+	db := testDB(t)
+	defer db.Close()
+
+	uuid := mergeFixture(t, db, 10, "lunch", "2025-03-16 10:00:00")
+	list, err := ListExpenses(db, mergeDate)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListExpenses: %v, %v", list, err)
+	}
+	if list[0].UUID != uuid {
+		t.Errorf("ListExpenses UUID: got %q, want %q", list[0].UUID, uuid)
+	}
+	if err := DeleteExpense(db, list[0].ID); err != nil {
+		t.Fatalf("DeleteExpense: %v", err)
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM deleted_expenses WHERE uuid = ?", uuid).Scan(&n); err != nil || n != 1 {
+		t.Errorf("deleted_expenses rows for %s: got %d (%v), want 1", uuid, n, err)
+	}
+}
+
+func TestLegacyExpenseUUIDIsStable(t *testing.T) {
+	a := legacyExpenseUUID(1, "2025-03-15 10:30:00", 10, "lunch", "food", "2025-03-15 10:30:00")
+	b := legacyExpenseUUID(1, "2025-03-15 10:30:00", 10, "lunch", "food", "2025-03-15 10:30:00")
+	c := legacyExpenseUUID(1, "2025-03-15 10:30:00", 11, "lunch", "food", "2025-03-15 10:30:00")
+	if a != b {
+		t.Errorf("legacyExpenseUUID not deterministic: %s vs %s", a, b)
+	}
+	if a == c {
+		t.Error("legacyExpenseUUID should differ for different rows")
+	}
+	if len(a) != 36 {
+		t.Errorf("legacyExpenseUUID length: got %d, want 36", len(a))
+	}
+}
@@ -1,7 +1,10 @@
 package database
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -10,18 +13,47 @@ const createMigrationsTable = `
 CREATE TABLE IF NOT EXISTS _migrations (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	name TEXT NOT NULL UNIQUE,
+	checksum TEXT DEFAULT NULL,
 	applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );`
 
+// ErrChecksumMismatch is returned when an applied migration no longer matches
+// its definition (its SQL was edited after it ran).
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// ErrMigrationDropsData is returned by MigrateDown, without force, when a migration
+// to revert would delete user data.
+var ErrMigrationDropsData = errors.New("reverting this migration deletes data")
+
+// migration is a single schema change. up/upFunc apply it, down/downFunc revert it.
+// SQL runs before the Go function in both directions. dropsData says what reverting
+// it deletes, if anything; such a migration is only reverted with force.
+type migration struct {
+	name      string
+	up        string
+	upFunc    func(tx *sql.Tx) error
+	down      string
+	downFunc  func(tx *sql.Tx) error
+	dropsData string
+}
+
+// checksum identifies the migration's definition so edits after it was applied are detected.
+// Go functions cannot be hashed, so only the name and SQL are covered.
+func (m migration) checksum() string {
+	h := sha256.New()
+	h.Write([]byte(m.name))
+	h.Write([]byte{0})
+	h.Write([]byte(m.up))
+	h.Write([]byte{0})
+	h.Write([]byte(m.down))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // migrations run in order. Add new migrations to the end of the slice.
-var migrations = []struct {
-	name   string
-	sql    string
-	goFunc func(db *sql.DB) error
-}{
+var migrations = []migration{
 	{
 		name: "001_initial",
-		sql: `
+		up: `
 CREATE TABLE IF NOT EXISTS expenses (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	date DATETIME NOT NULL,
@@ -31,75 +63,229 @@ CREATE TABLE IF NOT EXISTS expenses (
 	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );`,
+		down:      `DROP TABLE IF EXISTS expenses;`,
+		dropsData: "every expense",
+	},
+	{
+		name:     "002_utc_to_local_timezone",
+		upFunc:   migrateUTCToLocal,
+		downFunc: migrateLocalToUTC,
 	},
 	{
-		name:   "002_utc_to_local_timezone",
-		goFunc: migrateUTCToLocal,
+		name: "003_expense_uuid",
+		up: `
+ALTER TABLE expenses ADD COLUMN uuid TEXT DEFAULT NULL;
+CREATE TABLE IF NOT EXISTS deleted_expenses (
+	uuid TEXT PRIMARY KEY,
+	deleted_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`,
+		upFunc: backfillExpenseUUIDs,
+		down: `
+DROP INDEX IF EXISTS idx_expenses_uuid;
+DROP TABLE IF EXISTS deleted_expenses;
+ALTER TABLE expenses DROP COLUMN uuid;`,
+		dropsData: "the expense UUIDs and deletions that merge matches on",
 	},
 }
 
+// MigrationStatus describes one known migration and whether it has been applied.
+type MigrationStatus struct {
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool // applied, but the definition changed since
+}
+
 // Migrate runs all pending migrations on db.
 func Migrate(db *sql.DB) error {
-	if _, err := db.Exec(createMigrationsTable); err != nil {
-		return fmt.Errorf("create migrations table: %w", err)
+	_, err := MigrateUp(db)
+	return err
+}
+
+// MigrateUp runs all pending migrations and returns the names of those applied.
+// Each migration runs in its own transaction together with its _migrations record,
+// so a failure leaves the database as it was before that migration.
+func MigrateUp(db *sql.DB) ([]string, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
 	}
 
+	var names []string
 	for _, m := range migrations {
-		applied, err := migrationApplied(db, m.name)
-		if err != nil {
-			return fmt.Errorf("check migration %s: %w", m.name, err)
-		}
-		if applied {
+		if rec, ok := applied[m.name]; ok {
+			if rec.checksum != m.checksum() {
+				return names, fmt.Errorf("%w: %s", ErrChecksumMismatch, m.name)
+			}
 			continue
 		}
-
-		if m.sql != "" {
-			if _, err := db.Exec(m.sql); err != nil {
-				return fmt.Errorf("migration %s: %w", m.name, err)
+		if err := runInTx(db, func(tx *sql.Tx) error {
+			if m.up != "" {
+				if _, err := tx.Exec(m.up); err != nil {
+					return err
+				}
+			}
+			if m.upFunc != nil {
+				if err := m.upFunc(tx); err != nil {
+					return err
+				}
 			}
+			_, err := tx.Exec("INSERT INTO _migrations (name, checksum) VALUES (?, ?)", m.name, m.checksum())
+			return err
+		}); err != nil {
+			return names, fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		names = append(names, m.name)
+	}
+	return names, nil
+}
+
+// MigrateDown reverts the last steps applied migrations (newest first) and
+// returns the names of those reverted. Unless force is set it reverts nothing when
+// one of them would delete data, and returns ErrMigrationDropsData.
+func MigrateDown(db *sql.DB, steps int, force bool) ([]string, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var revert []migration
+	for i := len(migrations) - 1; i >= 0 && len(revert) < steps; i-- {
+		if _, ok := applied[migrations[i].name]; ok {
+			revert = append(revert, migrations[i])
 		}
-		if m.goFunc != nil {
-			if err := m.goFunc(db); err != nil {
-				return fmt.Errorf("migration %s: %w", m.name, err)
+	}
+	for _, m := range revert {
+		if m.down == "" && m.downFunc == nil {
+			return nil, fmt.Errorf("migration %s cannot be reverted", m.name)
+		}
+		if m.dropsData != "" && !force {
+			return nil, fmt.Errorf("migration %s: %w (%s)", m.name, ErrMigrationDropsData, m.dropsData)
+		}
+	}
+
+	var names []string
+	for _, m := range revert {
+		if err := runInTx(db, func(tx *sql.Tx) error {
+			if m.down != "" {
+				if _, err := tx.Exec(m.down); err != nil {
+					return err
+				}
 			}
+			if m.downFunc != nil {
+				if err := m.downFunc(tx); err != nil {
+					return err
+				}
+			}
+			_, err := tx.Exec("DELETE FROM _migrations WHERE name = ?", m.name)
+			return err
+		}); err != nil {
+			return names, fmt.Errorf("revert migration %s: %w", m.name, err)
 		}
-		if _, err := db.Exec("INSERT INTO _migrations (name) VALUES (?)", m.name); err != nil {
-			return fmt.Errorf("record migration %s: %w", m.name, err)
+		names = append(names, m.name)
+	}
+	return names, nil
+}
+
+// GetMigrationStatus returns every known migration in order with its applied state.
+func GetMigrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Name: m.name}
+		if rec, ok := applied[m.name]; ok {
+			s.Applied = true
+			s.AppliedAt = rec.appliedAt
+			s.Modified = rec.checksum != m.checksum()
 		}
+		statuses = append(statuses, s)
 	}
+	return statuses, nil
+}
 
-	return nil
+// appliedMigration is a row of the _migrations table.
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
 }
 
-func migrationApplied(db *sql.DB, name string) (bool, error) {
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM _migrations WHERE name = ?", name).Scan(&count)
-	return count > 0, err
+// ensureMigrationsTable creates the _migrations table and upgrades tables created
+// before checksums were recorded. Existing rows get the current checksum.
+func ensureMigrationsTable(db *sql.DB) error {
+	if _, err := db.Exec(createMigrationsTable); err != nil {
+		return fmt.Errorf("create migrations table: %w", err)
+	}
+
+	var hasChecksum int
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('_migrations') WHERE name = 'checksum'").Scan(&hasChecksum)
+	if err != nil {
+		return fmt.Errorf("inspect migrations table: %w", err)
+	}
+	if hasChecksum == 0 {
+		if _, err := db.Exec("ALTER TABLE _migrations ADD COLUMN checksum TEXT DEFAULT NULL"); err != nil {
+			return fmt.Errorf("add migrations checksum: %w", err)
+		}
+	}
+
+	for _, m := range migrations {
+		if _, err := db.Exec("UPDATE _migrations SET checksum = ? WHERE name = ? AND checksum IS NULL", m.checksum(), m.name); err != nil {
+			return fmt.Errorf("backfill checksum %s: %w", m.name, err)
+		}
+	}
+	return nil
 }
 
-// migrateUTCToLocal converts all expense date values from UTC to local timezone.
-// Previously dates were stored with UTC offset (e.g. "2026-02-28 23:30:00+00:00").
-// Now we store local time without offset so SQLite strftime works on local dates.
-func migrateUTCToLocal(db *sql.DB) error {
-	rows, err := db.Query("SELECT id, date FROM expenses")
+func appliedMigrations(db *sql.DB) (map[string]appliedMigration, error) {
+	rows, err := db.Query("SELECT name, COALESCE(checksum, ''), applied_at FROM _migrations")
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("read migrations: %w", err)
 	}
 	defer rows.Close()
 
-	type record struct {
-		id   int64
-		date string
-	}
-	var records []record
+	applied := make(map[string]appliedMigration)
 	for rows.Next() {
-		var r record
-		if err := rows.Scan(&r.id, &r.date); err != nil {
-			return err
+		var name string
+		var rec appliedMigration
+		if err := rows.Scan(&name, &rec.checksum, &rec.appliedAt); err != nil {
+			return nil, fmt.Errorf("read migrations: %w", err)
 		}
-		records = append(records, r)
+		applied[name] = rec
 	}
-	if err := rows.Err(); err != nil {
+	return applied, rows.Err()
+}
+
+// runInTx runs fn in a transaction, committing on success and rolling back on error.
+func runInTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// migrateUTCToLocal converts all expense date values from UTC to local timezone.
+// Previously dates were stored with UTC offset (e.g. "2026-02-28 23:30:00+00:00").
+// Now we store local time without offset so SQLite strftime works on local dates.
+func migrateUTCToLocal(tx *sql.Tx) error {
+	records, err := readExpenseDates(tx)
+	if err != nil {
 		return err
 	}
 
@@ -125,9 +311,88 @@ func migrateUTCToLocal(db *sql.DB) error {
 			continue
 		}
 		localStr := t.Local().Format(DateTimeStorageFormat)
-		if _, err := db.Exec("UPDATE expenses SET date = ? WHERE id = ?", localStr, r.id); err != nil {
+		if _, err := tx.Exec("UPDATE expenses SET date = ? WHERE id = ?", localStr, r.id); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// migrateLocalToUTC reverts migrateUTCToLocal, storing dates as UTC with an explicit offset.
+func migrateLocalToUTC(tx *sql.Tx) error {
+	records, err := readExpenseDates(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		t, err := time.ParseInLocation(DateTimeStorageFormat, r.date, time.Local)
+		if err != nil {
+			continue
+		}
+		utcStr := t.UTC().Format("2006-01-02 15:04:05.999999-07:00")
+		if _, err := tx.Exec("UPDATE expenses SET date = ? WHERE id = ?", utcStr, r.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expenseDate is the raw stored date of an expense, read for date migrations.
+type expenseDate struct {
+	id   int64
+	date string
+}
+
+func readExpenseDates(tx *sql.Tx) ([]expenseDate, error) {
+	rows, err := tx.Query("SELECT id, CAST(date AS TEXT) FROM expenses")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []expenseDate
+	for rows.Next() {
+		var r expenseDate
+		if err := rows.Scan(&r.id, &r.date); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// backfillExpenseUUIDs gives every existing expense a UUID and makes uuid unique.
+// The UUIDs are derived from the row itself, so copies of the same database
+// migrated on different devices agree on them and can later be merged.
+func backfillExpenseUUIDs(tx *sql.Tx) error {
+	rows, err := tx.Query(`
+		SELECT id, CAST(date AS TEXT), amount, COALESCE(description, ''), expense_type, COALESCE(CAST(created_at AS TEXT), '')
+		FROM expenses WHERE uuid IS NULL`)
+	if err != nil {
+		return err
+	}
+	ids := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var date, desc, typ, createdAt string
+		var amount float64
+		if err := rows.Scan(&id, &date, &amount, &desc, &typ, &createdAt); err != nil {
+			rows.Close()
+			return err
+		}
+		ids[id] = legacyExpenseUUID(id, date, amount, desc, typ, createdAt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, uuid := range ids {
+		if _, err := tx.Exec("UPDATE expenses SET uuid = ? WHERE id = ?", uuid, id); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_expenses_uuid ON expenses(uuid)")
+	return err
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestMigrationStatus(t *testing.T) {
+	db := testDB(t)
+	defer db.Close()
+
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("GetMigrationStatus: %v", err)
+	}
+	if len(statuses) != len(migrations) {
+		t.Fatalf("GetMigrationStatus: got %d, want %d", len(statuses), len(migrations))
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.Modified {
+			t.Errorf("%s: applied=%v modified=%v, want applied and unmodified", s.Name, s.Applied, s.Modified)
+		}
+	}
+
+	// Running again applies nothing
+	names, err := MigrateUp(db)
+	if err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("MigrateUp second run applied %v, want none", names)
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db := testDB(t)
+	defer db.Close()
+
+	date := time.Date(2025, 3, 15, 10, 30, 0, 0, time.Local)
+	if _, err := CreateExpense(db, date, 10, "lunch", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+
+	names, err := MigrateDown(db, 2, true)
+	if err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	if len(names) != 2 || names[0] != "003_expense_uuid" || names[1] != "002_utc_to_local_timezone" {
+		t.Fatalf("MigrateDown reverted %v, want [003_expense_uuid 002_utc_to_local_timezone]", names)
+	}
+
+	names, err = MigrateUp(db)
+	if err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("MigrateUp applied %v, want 2 migrations", names)
+	}
+
+	// Round trip keeps the local date
+	list, err := ListExpenses(db, date)
+	if err != nil {
+		t.Fatalf("ListExpenses: %v", err)
+	}
+	if len(list) != 1 || !list[0].Date.Equal(date) {
+		t.Errorf("after round trip: got %v, want one expense at %v", list, date)
+	}
+}
+
+func TestMigrateDownKeepsData(t *testing.T) {
+	db := testDB(t)
+	defer db.Close()
+
+	if _, err := CreateExpense(db, time.Now(), 10, "lunch", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	for _, steps := range []int{1, len(migrations)} {
+		names, err := MigrateDown(db, steps, false)
+		if !errors.Is(err, ErrMigrationDropsData) || len(names) != 0 {
+			t.Errorf("MigrateDown(%d) without force: reverted %v, err %v; want none and ErrMigrationDropsData", steps, names, err)
+		}
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM expenses WHERE uuid IS NOT NULL").Scan(&n); err != nil || n != 1 {
+		t.Errorf("after refused reverts: got %d expenses with a uuid (err %v), want 1", n, err)
+	}
+
+	names, err := MigrateDown(db, len(migrations), true)
+	if err != nil || len(names) != len(migrations) {
+		t.Fatalf("MigrateDown with force: reverted %v, err %v; want all", names, err)
+	}
+}
+
+func TestMigrateDetectsEditedMigration(t *testing.T) {
+	db := testDB(t)
+	defer db.Close()
+
+	if _, err := db.Exec("UPDATE _migrations SET checksum = 'edited' WHERE name = '001_initial'"); err != nil {
+		t.Fatalf("tamper checksum: %v", err)
+	}
+	if err := Migrate(db); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Migrate err = %v, want ErrChecksumMismatch", err)
+	}
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("GetMigrationStatus: %v", err)
+	}
+	if !statuses[0].Modified {
+		t.Error("001_initial should be reported as modified")
+	}
+}
+
+func TestMigrateUpgradesLegacyMigrationsTable(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open in-memory db: %v", err)
+	}
+	defer db.Close()
+
+	// Table layout before checksums were recorded
+	if _, err := db.Exec(`CREATE TABLE _migrations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("GetMigrationStatus: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.Modified {
+			t.Errorf("%s: applied=%v modified=%v", s.Name, s.Applied, s.Modified)
+		}
+	}
+}
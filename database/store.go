@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+// Store is the expense storage used by the CLI and TUI. Month arguments select
+// the calendar month (local time) containing the given date.
+type Store interface {
+	ListExpenses(month time.Time) ([]types.Expense, error)
+	GetExpensesSummary(month time.Time) ([]types.CategorySummary, error)
+	GetMonthlyReport() ([]types.MonthlyReport, error)
+	GetDailyTotals(month time.Time) ([]types.DailyTotal, error)
+	GetTotalExpenses(month time.Time) (float64, error)
+	// GetDescriptionHistory returns the descriptions used since the given date, most
+	// used first (then most recent), at most limit of them (all when limit <= 0).
+	// Descriptions differing only in case count as one.
+	GetDescriptionHistory(since time.Time, limit int) ([]types.DescriptionHistory, error)
+	// GetExpense returns the expense with id, or ErrExpenseNotFound.
+	GetExpense(id int64) (types.Expense, error)
+	CreateExpense(date time.Time, amount float64, description string, expenseType types.ExpenseType) (int64, error)
+	DeleteExpense(id int64) error
+	// DeleteExpenses and UpdateExpensesType change several expenses at once: all or none.
+	DeleteExpenses(ids []int64) error
+	UpdateExpensesType(ids []int64, expenseType types.ExpenseType) error
+	// DataVersion returns a number that changes when the expenses may have changed,
+	// including from outside (another sana writing to the same database). Only
+	// comparing it with an earlier one means anything.
+	DataVersion() (int64, error)
+	Close() error
+}
+
+// SQLiteStore is a Store backed by a SQLite database.
+type SQLiteStore struct {
+	db *sql.DB
+
+	// The connection DataVersion asks, opened on first use: SQLite's data_version
+	// only compares within one connection
+	versionMu   sync.Mutex
+	versionConn *sql.Conn
+}
+
+// NewSQLiteStore returns a Store using db. Closing the store closes db.
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+// DB returns the underlying database, for SQLite-only operations such as migrations and merging.
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
+func (s *SQLiteStore) ListExpenses(month time.Time) ([]types.Expense, error) {
+	return ListExpenses(s.db, month)
+}
+
+func (s *SQLiteStore) GetExpensesSummary(month time.Time) ([]types.CategorySummary, error) {
+	return GetExpensesSummary(s.db, month)
+}
+
+func (s *SQLiteStore) GetMonthlyReport() ([]types.MonthlyReport, error) {
+	return GetMonthlyReport(s.db)
+}
+
+func (s *SQLiteStore) GetDailyTotals(month time.Time) ([]types.DailyTotal, error) {
+	return GetDailyTotals(s.db, month)
+}
+
+func (s *SQLiteStore) GetTotalExpenses(month time.Time) (float64, error) {
+	return GetTotalExpenses(s.db, month)
+}
+
+func (s *SQLiteStore) GetDescriptionHistory(since time.Time, limit int) ([]types.DescriptionHistory, error) {
+	return GetDescriptionHistory(s.db, since, limit)
+}
+
+func (s *SQLiteStore) GetExpense(id int64) (types.Expense, error) {
+	return GetExpense(s.db, id)
+}
+
+func (s *SQLiteStore) CreateExpense(date time.Time, amount float64, description string, expenseType types.ExpenseType) (int64, error) {
+	return CreateExpense(s.db, date, amount, description, expenseType)
+}
+
+func (s *SQLiteStore) DeleteExpense(id int64) error {
+	return DeleteExpense(s.db, id)
+}
+
+func (s *SQLiteStore) DeleteExpenses(ids []int64) error {
+	return DeleteExpenses(s.db, ids)
+}
+
+func (s *SQLiteStore) UpdateExpensesType(ids []int64, expenseType types.ExpenseType) error {
+	return UpdateExpensesType(s.db, ids, expenseType)
+}
+
+// dataVersioner is a driver connection with its own data version: an encrypted
+// database, whose in-memory SQLite never sees other processes' writes to the file.
+type dataVersioner interface {
+	dataVersion() (int64, error)
+}
+
+// DataVersion returns SQLite's data_version, which changes when another connection
+// commits: another process, or another connection of the pool this store writes through.
+// For an encrypted database it counts the times another process's writes to the
+// file have been loaded, loading them first.
+func (s *SQLiteStore) DataVersion() (int64, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+
+	var version int64
+	if s.db.Stats().MaxOpenConnections == 1 {
+		// A pool of one always asks the same connection, and holding it here would
+		// leave none for the queries
+		conn, err := s.db.Conn(context.Background())
+		if err != nil {
+			return 0, err
+		}
+		defer conn.Close()
+		var versioned bool
+		err = conn.Raw(func(dc any) error {
+			if c, ok := dc.(dataVersioner); ok {
+				versioned = true
+				version, err = c.dataVersion()
+				return err
+			}
+			return nil
+		})
+		if err != nil || versioned {
+			return version, err
+		}
+		err = conn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&version)
+		return version, err
+	}
+	if s.versionConn == nil {
+		conn, err := s.db.Conn(context.Background())
+		if err != nil {
+			return 0, err
+		}
+		s.versionConn = conn
+	}
+	err := s.versionConn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&version)
+	return version, err
+}
+
+func (s *SQLiteStore) Close() error {
+	s.versionMu.Lock()
+	if s.versionConn != nil {
+		s.versionConn.Close()
+		s.versionConn = nil
+	}
+	s.versionMu.Unlock()
+	return s.db.Close()
+}
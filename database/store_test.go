@@ -0,0 +1,251 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+// TestStores runs the same checks against every Store implementation. Each store
+// comes with a way to backdate an expense's updated_at, which the Store interface
+// doesn't offer, to check that changes bump it.
+func TestStores(t *testing.T) {
+	longAgo := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	stores := map[string]func(t *testing.T) (Store, func(id int64)){
+		"sqlite": func(t *testing.T) (Store, func(id int64)) {
+			db := testDB(t)
+			db.SetMaxOpenConns(1) // one :memory: database per connection
+			return NewSQLiteStore(db), func(id int64) {
+				if _, err := db.Exec("UPDATE expenses SET updated_at = ? WHERE id = ?", longAgo.Format("2006-01-02 15:04:05"), id); err != nil {
+					t.Fatalf("backdate: %v", err)
+				}
+			}
+		},
+		"memory": func(t *testing.T) (Store, func(id int64)) {
+			s := NewMemoryStore()
+			return s, func(id int64) {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				for i := range s.expenses {
+					if s.expenses[i].ID == id {
+						s.expenses[i].UpdatedAt = longAgo
+					}
+				}
+			}
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s, backdate := newStore(t)
+			defer s.Close()
+			testStore(t, s, backdate)
+		})
+	}
+}
+
+func testStore(t *testing.T, s Store, backdate func(id int64)) {
+	march := time.Date(2025, 3, 15, 10, 30, 0, 0, time.Local)
+	april := time.Date(2025, 4, 2, 9, 0, 0, 0, time.Local)
+
+	lunch, err := s.CreateExpense(march, 10, "lunch", types.ExpenseTypeFood)
+	if err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	if _, err := s.CreateExpense(march.Add(time.Hour), 25, "dinner", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	if _, err := s.CreateExpense(march.Add(-time.Hour), 40, "bus pass", types.ExpenseTypeTransport); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	if _, err := s.CreateExpense(april, 5, "coffee", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+
+	list, err := s.ListExpenses(march)
+	if err != nil {
+		t.Fatalf("ListExpenses: %v", err)
+	}
+	var descs []string
+	for _, e := range list {
+		descs = append(descs, e.Description)
+	}
+	if len(descs) != 3 || descs[0] != "dinner" || descs[1] != "lunch" || descs[2] != "bus pass" {
+		t.Errorf("ListExpenses: got %v, want [dinner lunch bus pass]", descs)
+	}
+	if list[1].UUID == "" {
+		t.Error("ListExpenses: expense has no UUID")
+	}
+
+	summary, err := s.GetExpensesSummary(march)
+	if err != nil {
+		t.Fatalf("GetExpensesSummary: %v", err)
+	}
+	if len(summary) != 2 || summary[0].Category != "Transport" || summary[1].Total != 35 || summary[1].Count != 2 {
+		t.Errorf("GetExpensesSummary: got %+v, want Transport 40 then Food 35 (2)", summary)
+	}
+
+	total, err := s.GetTotalExpenses(march)
+	if err != nil {
+		t.Fatalf("GetTotalExpenses: %v", err)
+	}
+	if total != 75 {
+		t.Errorf("GetTotalExpenses: got %.2f, want 75", total)
+	}
+
+	report, err := s.GetMonthlyReport()
+	if err != nil {
+		t.Fatalf("GetMonthlyReport: %v", err)
+	}
+	if len(report) != 2 || report[0].Month.Format("2006-01") != "2025-04" || report[1].Total != 75 {
+		t.Errorf("GetMonthlyReport: got %+v, want 2025-04 then 2025-03 (75)", report)
+	}
+
+	snack, err := s.CreateExpense(march.AddDate(0, 0, -12), 8, "snack", types.ExpenseTypeFood)
+	if err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	daily, err := s.GetDailyTotals(march)
+	if err != nil {
+		t.Fatalf("GetDailyTotals: %v", err)
+	}
+	if len(daily) != 2 || daily[0].Day.Format("2006-01-02") != "2025-03-03" || daily[0].Total != 8 ||
+		daily[1].Day.Day() != 15 || daily[1].Total != 75 || daily[1].Count != 3 {
+		t.Errorf("GetDailyTotals: got %+v, want 2025-03-03 (8) then 2025-03-15 (75, 3)", daily)
+	}
+	got, err := s.GetExpense(snack)
+	if err != nil {
+		t.Fatalf("GetExpense: %v", err)
+	}
+	if got.ID != snack || got.Amount != 8 || got.Description != "snack" || got.Type != types.ExpenseTypeFood || got.Date.Day() != 3 {
+		t.Errorf("GetExpense: got %+v, want snack 8 food on the 3rd", got)
+	}
+	if err := s.DeleteExpense(snack); err != nil {
+		t.Fatalf("DeleteExpense: %v", err)
+	}
+
+	if _, err := s.GetExpense(snack); !errors.Is(err, ErrExpenseNotFound) {
+		t.Errorf("GetExpense after delete: got %v, want ErrExpenseNotFound", err)
+	}
+
+	if err := s.DeleteExpense(lunch); err != nil {
+		t.Fatalf("DeleteExpense: %v", err)
+	}
+	total, err = s.GetTotalExpenses(march)
+	if err != nil {
+		t.Fatalf("GetTotalExpenses: %v", err)
+	}
+	if total != 65 {
+		t.Errorf("GetTotalExpenses after delete: got %.2f, want 65", total)
+	}
+
+	list, err = s.ListExpenses(march)
+	if err != nil {
+		t.Fatalf("ListExpenses: %v", err)
+	}
+	ids := []int64{list[0].ID, list[1].ID}
+	backdate(ids[0])
+	if err := s.UpdateExpensesType(ids, types.ExpenseTypeOther); err != nil {
+		t.Fatalf("UpdateExpensesType: %v", err)
+	}
+	// The new category wins when merging
+	if got, err := s.GetExpense(ids[0]); err != nil || got.UpdatedAt.Year() <= 2000 {
+		t.Errorf("GetExpense after UpdateExpensesType: updated_at %v (%v), want it bumped", got.UpdatedAt, err)
+	}
+	summary, err = s.GetExpensesSummary(march)
+	if err != nil {
+		t.Fatalf("GetExpensesSummary: %v", err)
+	}
+	if len(summary) != 1 || summary[0].Category != "Other" || summary[0].Count != 2 {
+		t.Errorf("GetExpensesSummary after UpdateExpensesType: got %+v, want Other (2)", summary)
+	}
+
+	if err := s.DeleteExpenses(ids); err != nil {
+		t.Fatalf("DeleteExpenses: %v", err)
+	}
+	if list, err = s.ListExpenses(march); err != nil || len(list) != 0 {
+		t.Errorf("ListExpenses after DeleteExpenses: got %d expenses (%v), want 0", len(list), err)
+	}
+
+	// April's coffee is before since; case doesn't split a description
+	june := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	for i, e := range []struct {
+		desc   string
+		amount float64
+		typ    types.ExpenseType
+	}{
+		{"rent", 900, types.ExpenseTypeBills},
+		{"Coffee", 3, types.ExpenseTypeShopping},
+		{"coffee", 4, types.ExpenseTypeFood},
+		{"coffee", 5, types.ExpenseTypeFood},
+		{"Coffee", 3.5, types.ExpenseTypeShopping},
+		{"", 1, types.ExpenseTypeOther},
+		{"bus", 2, types.ExpenseTypeTransport},
+	} {
+		if _, err := s.CreateExpense(june.AddDate(0, 0, i), e.amount, e.desc, e.typ); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	history, err := s.GetDescriptionHistory(june, 0)
+	if err != nil {
+		t.Fatalf("GetDescriptionHistory: %v", err)
+	}
+	want := []types.DescriptionHistory{
+		{Description: "Coffee", Type: types.ExpenseTypeShopping, Amount: 3.5, Count: 4},
+		{Description: "bus", Type: types.ExpenseTypeTransport, Amount: 2, Count: 1},
+		{Description: "rent", Type: types.ExpenseTypeBills, Amount: 900, Count: 1},
+	}
+	if !slices.Equal(history, want) {
+		t.Errorf("GetDescriptionHistory: got %+v, want %+v", history, want)
+	}
+	if history, err := s.GetDescriptionHistory(june, 1); err != nil || len(history) != 1 {
+		t.Errorf("GetDescriptionHistory(limit 1): got %d (%v), want 1", len(history), err)
+	}
+}
+
+func TestDataVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sana.db")
+	open := func() *sql.DB {
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			t.Fatalf("open %s: %v", path, err)
+		}
+		return db
+	}
+	mine := open()
+	if err := Migrate(mine); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	s := NewSQLiteStore(mine)
+	defer s.Close()
+	// Another process writing to the same file
+	other := NewSQLiteStore(open())
+	defer other.Close()
+
+	before, err := s.DataVersion()
+	if err != nil {
+		t.Fatalf("DataVersion: %v", err)
+	}
+	if again, _ := s.DataVersion(); again != before {
+		t.Errorf("DataVersion without changes: got %d, want %d", again, before)
+	}
+	if _, err := other.CreateExpense(time.Now(), 5, "coffee", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	if after, err := s.DataVersion(); err != nil || after == before {
+		t.Errorf("DataVersion after another connection's change: got %d, %v; want other than %d", after, err, before)
+	}
+
+	m := NewMemoryStore()
+	before, _ = m.DataVersion()
+	if _, err := m.CreateExpense(time.Now(), 5, "coffee", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	if after, _ := m.DataVersion(); after == before {
+		t.Errorf("MemoryStore.DataVersion after a change: got %d, want other than %d", after, before)
+	}
+}
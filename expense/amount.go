@@ -0,0 +1,190 @@
+package expense
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amounts can be written as arithmetic, e.g. "45.60/3" or "12+8.5*2", for splitting
+// a bill without a calculator. The evaluator only knows numbers, + - * /, unary
+// signs and parentheses, so nothing typed into the amount field can do more than
+// compute a number.
+
+// maxAmountDepth limits nested parentheses and signs, so the recursive evaluator
+// can't be made to recurse without bound.
+const maxAmountDepth = 32
+
+// AmountError is a syntax or arithmetic error in an amount, at a 1-based column.
+type AmountError struct {
+	Column int
+	Msg    string
+}
+
+func (e *AmountError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Msg, e.Column)
+}
+
+// IsAmountExpression reports whether s is a calculation rather than a plain
+// (possibly signed) number.
+func IsAmountExpression(s string) bool {
+	return strings.ContainsAny(strings.TrimLeft(strings.TrimSpace(s), "+-"), "+-*/()")
+}
+
+// EvalAmount evaluates an amount: a number, or arithmetic on numbers with + - * /
+// and parentheses, with the usual precedence. The result of a calculation is rounded
+// to cents (10/3 is 3.33); a plain number is kept as written. Errors are *AmountError.
+func EvalAmount(s string) (float64, error) {
+	p := amountParser{input: []rune(s)}
+	v, err := p.expr(0)
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return 0, p.unexpected()
+	}
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, &AmountError{Column: 1, Msg: "result is too large"}
+	}
+	if IsAmountExpression(s) {
+		v = math.Round(v*100) / 100
+	}
+	return v, nil
+}
+
+// amountParser is a recursive descent parser over:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { ("*" | "/") factor }
+//	factor = ("+" | "-") factor | "(" expr ")" | number
+type amountParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *amountParser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peek returns the next rune after spaces, or 0 at the end.
+func (p *amountParser) peek() rune {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// errorf returns an error at the current position.
+func (p *amountParser) errorf(format string, args ...any) error {
+	return &AmountError{Column: p.pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// unexpected returns an error for the rune at the current position, or for the end.
+func (p *amountParser) unexpected() error {
+	if p.pos >= len(p.input) {
+		return p.errorf("expected a number")
+	}
+	return p.errorf("unexpected %q", p.input[p.pos])
+}
+
+func (p *amountParser) expr(depth int) (float64, error) {
+	v, err := p.term(depth)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return v, nil
+		}
+		p.pos++
+		r, err := p.term(depth)
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			v += r
+		} else {
+			v -= r
+		}
+	}
+}
+
+func (p *amountParser) term(depth int) (float64, error) {
+	v, err := p.factor(depth)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return v, nil
+		}
+		opPos := p.pos
+		p.pos++
+		r, err := p.factor(depth)
+		if err != nil {
+			return 0, err
+		}
+		if op == '*' {
+			v *= r
+			continue
+		}
+		if r == 0 {
+			return 0, &AmountError{Column: opPos + 1, Msg: "division by zero"}
+		}
+		v /= r
+	}
+}
+
+func (p *amountParser) factor(depth int) (float64, error) {
+	if depth >= maxAmountDepth {
+		return 0, p.errorf("too deeply nested")
+	}
+	switch c := p.peek(); {
+	case c == '+' || c == '-':
+		p.pos++
+		v, err := p.factor(depth + 1)
+		if c == '-' {
+			v = -v
+		}
+		return v, err
+	case c == '(':
+		open := p.pos
+		p.pos++
+		v, err := p.expr(depth + 1)
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			if p.pos >= len(p.input) {
+				return 0, &AmountError{Column: open + 1, Msg: "unclosed '('"}
+			}
+			return 0, p.unexpected()
+		}
+		p.pos++
+		return v, nil
+	case c >= '0' && c <= '9' || c == '.':
+		return p.number()
+	}
+	return 0, p.unexpected()
+}
+
+// number reads a run of digits and decimal points; more than one point is an error.
+func (p *amountParser) number() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	text := string(p.input[start:p.pos])
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, &AmountError{Column: start + 1, Msg: fmt.Sprintf("invalid number %q", text)}
+	}
+	return v, nil
+}
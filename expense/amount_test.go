@@ -0,0 +1,86 @@
+package expense
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEvalAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"25.5", 25.5},
+		{" 42 ", 42},
+		{"1.234", 1.234}, // a plain number is kept as written
+		{".5", 0.5},
+		{"45.60/3", 15.2},
+		{"12+8.5*2", 29},
+		{"(12+8)*2", 40},
+		{"10/3", 3.33},
+		{"100 - 20 - 30", 50},
+		{"-5+10", 5},
+		{"2*-3", -6},
+		{"((1))", 1},
+	}
+	for _, tt := range tests {
+		got, err := EvalAmount(tt.in)
+		if err != nil {
+			t.Errorf("EvalAmount(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("EvalAmount(%q): got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEvalAmount_Errors(t *testing.T) {
+	tests := []struct {
+		in     string
+		column int
+		msg    string
+	}{
+		{"", 1, "expected a number"},
+		{"abc", 1, `unexpected 'a'`},
+		{"12+", 4, "expected a number"},
+		{"12+*3", 4, `unexpected '*'`},
+		{"(12+3", 1, "unclosed '('"},
+		{"12+3)", 5, `unexpected ')'`},
+		{"12 3", 4, `unexpected '3'`},
+		{"1.2.3", 1, `invalid number "1.2.3"`},
+		{"10/0", 3, "division by zero"},
+		{"10/(5-5)", 3, "division by zero"},
+		{strings.Repeat("(", 40) + "1" + strings.Repeat(")", 40), 33, "too deeply nested"},
+	}
+	for _, tt := range tests {
+		_, err := EvalAmount(tt.in)
+		var amountErr *AmountError
+		if !errors.As(err, &amountErr) {
+			t.Errorf("EvalAmount(%q): got %v, want an AmountError", tt.in, err)
+			continue
+		}
+		if amountErr.Column != tt.column || amountErr.Msg != tt.msg {
+			t.Errorf("EvalAmount(%q): got %q at %d, want %q at %d", tt.in, amountErr.Msg, amountErr.Column, tt.msg, tt.column)
+		}
+	}
+}
+
+func TestIsAmountExpression(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"12.50", false},
+		{"-12", false},
+		{"12-3", true},
+		{"(12)", true},
+		{"45.60/3", true},
+	}
+	for _, tt := range tests {
+		if got := IsAmountExpression(tt.in); got != tt.want {
+			t.Errorf("IsAmountExpression(%q): got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
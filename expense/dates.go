@@ -0,0 +1,161 @@
+package expense
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dates and months can be written the way people say them: "yesterday", "last fri",
+// "3 days ago", "15" (the 15th of this month) or "mar". Relative forms count from
+// now, which the parsers take as an argument so they can be tested at month and year
+// boundaries.
+
+// weekdays are the weekday names; any prefix of at least three letters matches.
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// months are the month names; any prefix of at least three letters matches.
+var months = []string{"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december"}
+
+// matchName returns the index in names of the name s abbreviates (at least three
+// letters), or -1.
+func matchName(names []string, s string) int {
+	if len(s) < 3 {
+		return -1
+	}
+	for i, name := range names {
+		if strings.HasPrefix(name, s) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseClock parses "HH:MM" or "HH:MM:SS" into hours, minutes and seconds.
+func parseClock(s string) (h, m, sec int, ok bool) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Hour(), t.Minute(), t.Second(), true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// parseDay parses the date part of a date, relative to now, into a local midnight.
+func parseDay(s string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch s {
+	case "", "today":
+		return today, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, true
+	}
+	// "03-15": this year
+	if t, err := time.ParseInLocation("1-2", s, time.Local); err == nil {
+		d := time.Date(now.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+		return d, d.Day() == t.Day() // Feb 29 outside a leap year
+	}
+	// "15": this month
+	if day, err := strconv.Atoi(s); err == nil {
+		d := time.Date(now.Year(), now.Month(), day, 0, 0, 0, 0, time.Local)
+		return d, day >= 1 && d.Month() == now.Month()
+	}
+	// "-2d", "-1w"
+	if strings.HasPrefix(s, "-") && len(s) > 2 {
+		days, ok := unitDays(s[len(s)-1:])
+		count, err := strconv.Atoi(s[1 : len(s)-1])
+		if ok && err == nil && count >= 0 {
+			return today.AddDate(0, 0, -count*days), true
+		}
+	}
+	fields := strings.Fields(s)
+	// "3 days ago"
+	if len(fields) == 3 && fields[2] == "ago" {
+		days, ok := unitDays(fields[1])
+		count, err := strconv.Atoi(fields[0])
+		if ok && err == nil && count >= 0 {
+			return today.AddDate(0, 0, -count*days), true
+		}
+		return time.Time{}, false
+	}
+	// "mon": the latest Monday, today included; "last mon": the one before today
+	last := len(fields) == 2 && fields[0] == "last"
+	if last {
+		fields = fields[1:]
+	}
+	if len(fields) == 1 {
+		if wd := matchName(weekdays, fields[0]); wd >= 0 {
+			back := (int(today.Weekday()) - wd + 7) % 7
+			if last && back == 0 {
+				back = 7
+			}
+			return today.AddDate(0, 0, -back), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// unitDays returns the number of days in a unit of "-2d" or "3 days ago".
+func unitDays(unit string) (int, bool) {
+	switch unit {
+	case "d", "day", "days":
+		return 1, true
+	case "w", "week", "weeks":
+		return 7, true
+	}
+	return 0, false
+}
+
+// parseDate is ParseDate relative to now.
+func parseDate(dateStr string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.Join(strings.Fields(dateStr), " "))
+	if s == "" || s == "today" {
+		return now, nil
+	}
+	// A trailing time of day sets the clock; otherwise it is now's
+	h, m, sec, nsec := now.Hour(), now.Minute(), now.Second(), now.Nanosecond()
+	if i := strings.LastIndex(s, " ") + 1; strings.Contains(s[i:], ":") {
+		var ok bool
+		if h, m, sec, ok = parseClock(s[i:]); !ok {
+			return time.Time{}, fmt.Errorf("time of day must be HH:MM or HH:MM:SS, got %q", s[i:])
+		}
+		nsec = 0
+		s = strings.TrimSpace(s[:i])
+	}
+	day, ok := parseDay(s, now)
+	if !ok {
+		return time.Time{}, fmt.Errorf("date must be YYYY-MM-DD, MM-DD, a day of the month (15), today, yesterday, a weekday (mon, last fri), -2d or 3 days ago, optionally followed by HH:MM; got %q", strings.TrimSpace(dateStr))
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, sec, nsec, time.Local), nil
+}
+
+// parseMonth is ParseMonth relative to now.
+func parseMonth(monthStr string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(monthStr))
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	switch s {
+	case "", "this":
+		return current, nil
+	case "last":
+		return current.AddDate(0, -1, 0), nil
+	}
+	if t, err := time.ParseInLocation("2006-01", s, time.Local); err == nil {
+		return t, nil
+	}
+	// "-1": months back from this one
+	if strings.HasPrefix(s, "-") {
+		if n, err := strconv.Atoi(s[1:]); err == nil && n >= 0 {
+			return current.AddDate(0, -n, 0), nil
+		}
+	}
+	// "mar": the latest March, this month included
+	if i := matchName(months, s); i >= 0 {
+		back := (int(now.Month()) - 1 - i + 12) % 12
+		return current.AddDate(0, -back, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("month must be YYYY-MM, a month name (mar), last or -N (months back); got %q", strings.TrimSpace(monthStr))
+}
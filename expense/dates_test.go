@@ -0,0 +1,118 @@
+package expense
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateRelative(t *testing.T) {
+	at := func(y int, mo time.Month, d, h, mi int) time.Time {
+		return time.Date(y, mo, d, h, mi, 0, 0, time.Local)
+	}
+	// A Wednesday early in a month, and New Year's Day (a Thursday)
+	mar5 := at(2025, 3, 5, 9, 15)
+	jan1 := at(2026, 1, 1, 9, 15)
+
+	tests := []struct {
+		in   string
+		now  time.Time
+		want time.Time
+	}{
+		{"yesterday", at(2025, 3, 1, 9, 15), at(2025, 2, 28, 9, 15)},
+		{"yesterday", jan1, at(2025, 12, 31, 9, 15)},
+		{"Yesterday", at(2024, 3, 1, 9, 15), at(2024, 2, 29, 9, 15)},
+		{"-2d", at(2025, 3, 1, 9, 15), at(2025, 2, 27, 9, 15)},
+		{"-1w", jan1, at(2025, 12, 25, 9, 15)},
+		{"3 days ago", jan1, at(2025, 12, 29, 9, 15)},
+		{"1 week ago", mar5, at(2025, 2, 26, 9, 15)},
+		{"-0d", mar5, at(2025, 3, 5, 9, 15)},
+
+		// Weekdays: the latest one, today included; "last" skips today
+		{"wed", mar5, at(2025, 3, 5, 9, 15)},
+		{"last wed", mar5, at(2025, 2, 26, 9, 15)},
+		{"mon", mar5, at(2025, 3, 3, 9, 15)},
+		{"last fri", mar5, at(2025, 2, 28, 9, 15)},
+		{"friday", jan1, at(2025, 12, 26, 9, 15)},
+		{"thu", jan1, at(2026, 1, 1, 9, 15)},
+
+		// Day of this month, month-day of this year
+		{"15", mar5, at(2025, 3, 15, 9, 15)},
+		{"31", mar5, at(2025, 3, 31, 9, 15)},
+		{"03-15", jan1, at(2026, 3, 15, 9, 15)},
+		{"12-31", jan1, at(2026, 12, 31, 9, 15)},
+		{"2-29", at(2024, 1, 10, 9, 15), at(2024, 2, 29, 9, 15)},
+
+		// Time of day, alone or after a date
+		{"14:30", mar5, at(2025, 3, 5, 14, 30)},
+		{"yesterday 23:59", jan1, at(2025, 12, 31, 23, 59)},
+		{"2025-06-10 08:05:30", mar5, time.Date(2025, 6, 10, 8, 5, 30, 0, time.Local)},
+		{"15  07:00", mar5, at(2025, 3, 15, 7, 0)},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.in, tt.now)
+		if err != nil {
+			t.Errorf("parseDate(%q, %s): %v", tt.in, tt.now.Format("2006-01-02"), err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q, %s): got %s, want %s", tt.in, tt.now.Format("2006-01-02"),
+				got.Format("2006-01-02 15:04:05"), tt.want.Format("2006-01-02 15:04:05"))
+		}
+	}
+}
+
+func TestParseDateRelativeErrors(t *testing.T) {
+	now := time.Date(2025, 2, 10, 12, 0, 0, 0, time.Local)
+	for _, in := range []string{
+		"0", "29", "32", // not a day of February 2025
+		"02-29", "13-01", // not a date in 2025
+		"mo", "last", "last 15", "next fri",
+		"-d", "-2m", "two days ago", "3 months ago",
+		"25:00", "yesterday 12:61", "12:3x",
+	} {
+		if got, err := parseDate(in, now); err == nil {
+			t.Errorf("parseDate(%q): got %s, want an error", in, got.Format("2006-01-02 15:04"))
+		}
+	}
+}
+
+func TestParseMonthRelative(t *testing.T) {
+	month := func(y int, m time.Month) time.Time { return time.Date(y, m, 1, 0, 0, 0, 0, time.Local) }
+	jan := time.Date(2026, 1, 20, 15, 0, 0, 0, time.Local)
+	jun := time.Date(2025, 6, 30, 23, 59, 0, 0, time.Local)
+
+	tests := []struct {
+		in   string
+		now  time.Time
+		want time.Time
+	}{
+		{"last", jan, month(2025, 12)},
+		{"LAST", jun, month(2025, 5)},
+		{"this", jan, month(2026, 1)},
+		{"-1", jan, month(2025, 12)},
+		{"-13", jan, month(2024, 12)},
+		{"-0", jun, month(2025, 6)},
+		{"mar", jan, month(2025, 3)},
+		{"jan", jan, month(2026, 1)},
+		{"december", jan, month(2025, 12)},
+		{"jun", jun, month(2025, 6)},
+		{"jul", jun, month(2024, 7)},
+		{"2024-02", jun, month(2024, 2)},
+	}
+	for _, tt := range tests {
+		got, err := parseMonth(tt.in, tt.now)
+		if err != nil {
+			t.Errorf("parseMonth(%q, %s): %v", tt.in, tt.now.Format("2006-01"), err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseMonth(%q, %s): got %s, want %s", tt.in, tt.now.Format("2006-01"), got.Format("2006-01"), tt.want.Format("2006-01"))
+		}
+	}
+
+	for _, in := range []string{"ma", "+1", "-x", "next", "2025-13"} {
+		if got, err := parseMonth(in, jun); err == nil {
+			t.Errorf("parseMonth(%q): got %s, want an error", in, got.Format("2006-01"))
+		}
+	}
+}
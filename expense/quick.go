@@ -0,0 +1,142 @@
+package expense
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+// A quick entry is an expense on one line, e.g. "25.5 coffee @food yesterday":
+//
+//	amount       the first word that is a number or a calculation (see EvalAmount); required
+//	@category    a word starting with @ and the start of a category name (@food, @pers)
+//	date         trailing words that are a date (see ParseDate), e.g. yesterday,
+//	             "last fri 08:30" or 2025-03-15; a bare day of the month like 15 is
+//	             not taken as a date here, so it can be the amount. Default: now
+//	description  the other words, in order; required
+//
+// Without @category the description's usual category is used (see InferType).
+
+// maxQuickDateWords is the most words a date can take, as in "3 days ago 14:30".
+const maxQuickDateWords = 4
+
+// QuickEntry is a parsed quick entry.
+type QuickEntry struct {
+	Amount      float64
+	AmountText  string // as written, e.g. "45.60/3"
+	Description string
+	Type        types.ExpenseType // "" when no @category was given
+	Date        time.Time
+	DateText    string // as written; "" for now
+}
+
+// ParseQuickEntry parses a quick entry such as "25.5 coffee @food yesterday".
+func ParseQuickEntry(line string) (QuickEntry, error) {
+	return parseQuickEntry(line, time.Now())
+}
+
+// parseQuickEntry is ParseQuickEntry relative to now.
+func parseQuickEntry(line string, now time.Time) (QuickEntry, error) {
+	var q QuickEntry
+	var words []string
+	for _, w := range strings.Fields(line) {
+		if !strings.HasPrefix(w, "@") {
+			words = append(words, w)
+			continue
+		}
+		t, err := parseCategory(w[1:])
+		if err != nil {
+			return q, err
+		}
+		q.Type = t
+	}
+
+	// The longest run of trailing words that is a date, leaving room for the amount
+	q.Date = now
+	for n := min(maxQuickDateWords, len(words)-1); n >= 1; n-- {
+		tail := words[len(words)-n:]
+		if _, err := strconv.Atoi(tail[0]); err == nil && (n < 3 || tail[2] != "ago") {
+			continue // "15" or "15 14:30": a number, not a date
+		}
+		text := strings.Join(tail, " ")
+		if date, err := parseDate(text, now); err == nil {
+			q.Date, q.DateText = date, text
+			words = words[:len(words)-n]
+			break
+		}
+	}
+
+	var desc []string
+	for _, w := range words {
+		if q.AmountText == "" {
+			if amount, err := EvalAmount(w); err == nil {
+				q.Amount, q.AmountText = amount, w
+				continue
+			}
+		}
+		desc = append(desc, w)
+	}
+	if q.AmountText == "" {
+		return q, fmt.Errorf(`missing amount, as in "25.5 coffee @food yesterday"`)
+	}
+	if q.Amount <= 0 {
+		return q, fmt.Errorf("amount must be a positive number")
+	}
+	q.Description = strings.Join(desc, " ")
+	if q.Description == "" {
+		return q, fmt.Errorf(`missing description, as in "%s coffee"`, q.AmountText)
+	}
+	return q, nil
+}
+
+// parseCategory returns the category name starts with, by its value ("personal_care")
+// or label ("personal care"), ignoring case. An exact match wins over a longer one.
+func parseCategory(name string) (types.ExpenseType, error) {
+	if name == "" {
+		return "", fmt.Errorf("missing category after @")
+	}
+	name = strings.ToLower(name)
+	var matches []types.ExpenseType
+	for _, t := range types.AllExpenseTypes() {
+		label := strings.ToLower(t.String())
+		if string(t) == name || label == name {
+			return t, nil
+		}
+		if strings.HasPrefix(string(t), name) || strings.HasPrefix(label, name) {
+			matches = append(matches, t)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("unknown category @%s: use one of %s", name, strings.Join(types.ExpenseTypeSuggestions(), ", "))
+	case 1:
+		return matches[0], nil
+	}
+	labels := make([]string, len(matches))
+	for i, t := range matches {
+		labels[i] = t.String()
+	}
+	return "", fmt.Errorf("ambiguous category @%s: %s", name, strings.Join(labels, " or "))
+}
+
+// AddQuickEntry creates the expense of a parsed quick entry. Without a category it
+// gets the description's usual one, or else other.
+func AddQuickEntry(store database.Store, q QuickEntry) (int64, types.ExpenseType, error) {
+	t := q.Type
+	if t == "" {
+		inferred, ok, err := InferType(store, q.Description)
+		if err != nil {
+			return 0, "", err
+		}
+		t = types.ExpenseTypeOther
+		if ok {
+			t = inferred
+		}
+	}
+	id, err := store.CreateExpense(q.Date, q.Amount, strings.TrimSpace(q.Description), t)
+	return id, t, err
+}
@@ -0,0 +1,81 @@
+package expense
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestParseQuickEntry(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 15, 0, 0, time.Local) // a Saturday
+	day := func(m time.Month, d, h, mi int) time.Time { return time.Date(2025, m, d, h, mi, 0, 0, time.Local) }
+
+	tests := []struct {
+		in   string
+		want QuickEntry
+	}{
+		{"25.5 coffee @food yesterday", QuickEntry{Amount: 25.5, AmountText: "25.5", Description: "coffee", Type: types.ExpenseTypeFood, Date: day(2, 28, 9, 15), DateText: "yesterday"}},
+		{"coffee 3", QuickEntry{Amount: 3, AmountText: "3", Description: "coffee", Date: now}},
+		{"@trans 45.60/3 taxi home", QuickEntry{Amount: 15.2, AmountText: "45.60/3", Description: "taxi home", Type: types.ExpenseTypeTransport, Date: now}},
+		{"12 lunch with Ann last fri 13:00", QuickEntry{Amount: 12, AmountText: "12", Description: "lunch with Ann", Date: day(2, 28, 13, 0), DateText: "last fri 13:00"}},
+		{"9 movie @ent 3 days ago", QuickEntry{Amount: 9, AmountText: "9", Description: "movie", Type: types.ExpenseTypeEntertainment, Date: day(2, 26, 9, 15), DateText: "3 days ago"}},
+		{"pizza 2 15", QuickEntry{Amount: 2, AmountText: "2", Description: "pizza 15", Date: now}}, // a bare day is not a date
+		{"4 @PERSONAL_CARE soap 2025-02-10", QuickEntry{Amount: 4, AmountText: "4", Description: "soap", Type: types.ExpenseTypePersonalCare, Date: day(2, 10, 9, 15), DateText: "2025-02-10"}},
+		{"yesterday", QuickEntry{}}, // the amount word can't be the date
+	}
+	for _, tt := range tests {
+		got, err := parseQuickEntry(tt.in, now)
+		if tt.want.AmountText == "" {
+			if err == nil {
+				t.Errorf("parseQuickEntry(%q): got %+v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseQuickEntry(%q): %v", tt.in, err)
+			continue
+		}
+		if got.Amount != tt.want.Amount || got.AmountText != tt.want.AmountText || got.Description != tt.want.Description ||
+			got.Type != tt.want.Type || !got.Date.Equal(tt.want.Date) || got.DateText != tt.want.DateText {
+			t.Errorf("parseQuickEntry(%q): got %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuickEntryErrors(t *testing.T) {
+	for _, in := range []string{"", "coffee", "0 coffee", "5 coffee @", "5 coffee @xyz", "5 coffee @e", "10", "10 @food", "10 yesterday"} {
+		if got, err := ParseQuickEntry(in); err == nil {
+			t.Errorf("ParseQuickEntry(%q): got %+v, want an error", in, got)
+		}
+	}
+}
+
+func TestAddQuickEntry(t *testing.T) {
+	store := database.NewMemoryStore()
+	if _, err := store.CreateExpense(time.Now().AddDate(0, 0, -1), 3, "coffee", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	tests := []struct {
+		in   string
+		want types.ExpenseType
+	}{
+		{"4 Coffee", types.ExpenseTypeFood}, // the usual category
+		{"4 coffee @shop", types.ExpenseTypeShopping},
+		{"4 stamps", types.ExpenseTypeOther},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuickEntry(tt.in)
+		if err != nil {
+			t.Fatalf("ParseQuickEntry(%q): %v", tt.in, err)
+		}
+		id, got, err := AddQuickEntry(store, q)
+		if err != nil || id <= 0 {
+			t.Fatalf("AddQuickEntry(%q): id %d, %v", tt.in, id, err)
+		}
+		if got != tt.want {
+			t.Errorf("AddQuickEntry(%q): category %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
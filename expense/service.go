@@ -1,9 +1,7 @@
 package expense
 
 import (
-	"database/sql"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
@@ -11,49 +9,69 @@ import (
 	"github.com/kyawphyothu/sana/types"
 )
 
-// ParseDate parses a date string into a local time.Time.
-// Accepts: "" or "today" (now), "YYYY-MM-DD", or "YYYY-MM-DD HH:MM:SS".
-// For date-only input, the current time's clock is applied.
+// ParseDate parses a date string into a local time.Time. Accepts:
+//   - "" or "today" (now), "yesterday"
+//   - "YYYY-MM-DD", "MM-DD" (this year) or a day of the month such as "15" (this month)
+//   - a weekday: "mon" is the latest Monday, today included; "last mon" the one before today
+//   - "-2d", "-1w", "3 days ago" or "2 weeks ago"
+//
+// Any of these may be followed by a time of day, "HH:MM" or "HH:MM:SS", which may
+// also stand alone for today. Without one, the current time's clock is applied.
 func ParseDate(dateStr string) (time.Time, error) {
-	s := strings.TrimSpace(dateStr)
-	if s == "" || strings.ToLower(s) == "today" {
-		return time.Now(), nil
-	}
-	// Try "YYYY-MM-DD HH:MM:SS" first
-	t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local)
-	if err == nil {
-		return t, nil
-	}
-	// Then "YYYY-MM-DD"
-	t, err = time.ParseInLocation("2006-01-02", s, time.Local)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("date must be YYYY-MM-DD or YYYY-MM-DD HH:MM:SS or 'today': %w", err)
-	}
-	now := time.Now()
-	return time.Date(t.Year(), t.Month(), t.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond(), time.Local), nil
+	return parseDate(dateStr, time.Now())
 }
 
-// ParseMonth parses a month string "YYYY-MM" into the first day of that month in local time.
-// Empty string returns the current month (first day).
+// ParseMonth parses a month string into the first day of that month in local time.
+// Accepts "YYYY-MM", "last" (the previous month), "-N" (N months back) and a month
+// name such as "mar" (the latest March, this month included). Empty string returns
+// the current month (first day).
 func ParseMonth(monthStr string) (time.Time, error) {
-	s := strings.TrimSpace(monthStr)
-	if s == "" {
-		now := time.Now()
-		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local), nil
+	return parseMonth(monthStr, time.Now())
+}
+
+// historyMonths is how far back description history looks, for suggestions and
+// inferred categories.
+const historyMonths = 12
+
+// DescriptionHistory returns the descriptions of the last year's expenses, most used
+// first, with their usual category and last amount; at most limit (all when limit <= 0).
+func DescriptionHistory(store database.Store, limit int) ([]types.DescriptionHistory, error) {
+	return store.GetDescriptionHistory(time.Now().AddDate(0, -historyMonths, 0), limit)
+}
+
+// FindDescription returns the entry of history for description, ignoring case and
+// surrounding spaces.
+func FindDescription(history []types.DescriptionHistory, description string) (types.DescriptionHistory, bool) {
+	desc := strings.TrimSpace(description)
+	for _, h := range history {
+		if strings.EqualFold(h.Description, desc) {
+			return h, true
+		}
 	}
-	t, err := time.ParseInLocation("2006-01", s, time.Local)
+	return types.DescriptionHistory{}, false
+}
+
+// InferType returns the category description is usually filed under in the last
+// year's expenses; ok is false for a description not used in that time.
+func InferType(store database.Store, description string) (t types.ExpenseType, ok bool, err error) {
+	history, err := DescriptionHistory(store, 0)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("month must be YYYY-MM: %w", err)
+		return "", false, err
 	}
-	return t, nil
+	h, ok := FindDescription(history, description)
+	return h.Type, ok, nil
 }
 
 // AddExpense validates and parses add-expense input, then creates the expense.
+// The amount may be a calculation (see EvalAmount).
 // All parsing and validation live here so CLI and TUI share one implementation.
 // Returns the new expense ID or an error (e.g. invalid amount, date, or DB error).
-func AddExpense(db *sql.DB, amountStr, description, typeStr, dateStr string) (int64, error) {
-	amount, err := strconv.ParseFloat(strings.TrimSpace(amountStr), 64)
-	if err != nil || amount <= 0 {
+func AddExpense(store database.Store, amountStr, description, typeStr, dateStr string) (int64, error) {
+	amount, err := EvalAmount(amountStr)
+	if err != nil {
+		return 0, fmt.Errorf("amount must be a positive number: %w", err)
+	}
+	if amount <= 0 {
 		return 0, fmt.Errorf("amount must be a positive number")
 	}
 	date, err := ParseDate(dateStr)
@@ -65,5 +83,5 @@ func AddExpense(db *sql.DB, amountStr, description, typeStr, dateStr string) (in
 		expType = types.ExpenseTypeOther
 	}
 	desc := strings.TrimSpace(description)
-	return database.CreateExpense(db, date, amount, desc, expType)
+	return store.CreateExpense(date, amount, desc, expType)
 }
@@ -9,6 +9,7 @@ import (
 	_ "modernc.org/sqlite"
 
 	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
 )
 
 // testDB returns an in-memory SQLite DB with migrations applied.
@@ -228,54 +229,54 @@ func TestAddExpense_Validation(t *testing.T) {
 	defer db.Close()
 
 	tests := []struct {
-		name     string
-		amount   string
-		desc     string
-		typeStr  string
-		dateStr  string
-		wantErr  bool
+		name        string
+		amount      string
+		desc        string
+		typeStr     string
+		dateStr     string
+		wantErr     bool
 		errContains string
 	}{
 		{
-			name:     "zero amount",
-			amount:   "0",
-			desc:     "x",
-			typeStr:  "food",
-			dateStr:  "2025-03-15",
-			wantErr:  true,
+			name:        "zero amount",
+			amount:      "0",
+			desc:        "x",
+			typeStr:     "food",
+			dateStr:     "2025-03-15",
+			wantErr:     true,
 			errContains: "amount must be a positive number",
 		},
 		{
-			name:     "negative amount",
-			amount:   "-10",
-			desc:     "x",
-			typeStr:  "food",
-			dateStr:  "2025-03-15",
-			wantErr:  true,
+			name:        "negative amount",
+			amount:      "-10",
+			desc:        "x",
+			typeStr:     "food",
+			dateStr:     "2025-03-15",
+			wantErr:     true,
 			errContains: "amount must be a positive number",
 		},
 		{
-			name:     "invalid amount",
-			amount:   "abc",
-			desc:     "x",
-			typeStr:  "food",
-			dateStr:  "2025-03-15",
-			wantErr:  true,
+			name:        "invalid amount",
+			amount:      "abc",
+			desc:        "x",
+			typeStr:     "food",
+			dateStr:     "2025-03-15",
+			wantErr:     true,
 			errContains: "amount must be a positive number",
 		},
 		{
-			name:     "invalid date",
-			amount:   "10",
-			desc:     "x",
-			typeStr:  "food",
-			dateStr:  "invalid",
-			wantErr:  true,
+			name:        "invalid date",
+			amount:      "10",
+			desc:        "x",
+			typeStr:     "food",
+			dateStr:     "invalid",
+			wantErr:     true,
 			errContains: "date must be",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			id, err := AddExpense(db, tt.amount, tt.desc, tt.typeStr, tt.dateStr)
+			id, err := AddExpense(database.NewSQLiteStore(db), tt.amount, tt.desc, tt.typeStr, tt.dateStr)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("AddExpense() err = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -296,7 +297,7 @@ func TestAddExpense_Success(t *testing.T) {
 	db := testDB(t)
 	defer db.Close()
 
-	id, err := AddExpense(db, "42.50", "coffee", "food", "2025-03-15")
+	id, err := AddExpense(database.NewSQLiteStore(db), "42.50", "coffee", "food", "2025-03-15")
 	if err != nil {
 		t.Fatalf("AddExpense: %v", err)
 	}
@@ -323,7 +324,7 @@ func TestAddExpense_DefaultTypeAndDate(t *testing.T) {
 	defer db.Close()
 
 	// Empty type -> other; empty date -> today
-	id, err := AddExpense(db, "1", "misc", "", "")
+	id, err := AddExpense(database.NewSQLiteStore(db), "1", "misc", "", "")
 	if err != nil {
 		t.Fatalf("AddExpense: %v", err)
 	}
@@ -350,3 +351,41 @@ func TestAddExpense_DefaultTypeAndDate(t *testing.T) {
 	}
 }
 
+func TestInferType(t *testing.T) {
+	store := database.NewMemoryStore()
+	now := time.Now()
+	for _, e := range []struct {
+		date time.Time
+		desc string
+		typ  types.ExpenseType
+	}{
+		{now.AddDate(-2, 0, 0), "gym", types.ExpenseTypeHealth}, // too old to count
+		{now.AddDate(0, -1, 0), "Coffee", types.ExpenseTypeShopping},
+		{now.AddDate(0, 0, -2), "coffee", types.ExpenseTypeFood},
+		{now.AddDate(0, 0, -1), "coffee", types.ExpenseTypeFood},
+	} {
+		if _, err := store.CreateExpense(e.date, 3, e.desc, e.typ); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+
+	tests := []struct {
+		desc   string
+		want   types.ExpenseType
+		wantOK bool
+	}{
+		{"coffee", types.ExpenseTypeFood, true},
+		{" COFFEE ", types.ExpenseTypeFood, true},
+		{"gym", "", false},
+		{"tea", "", false},
+	}
+	for _, tt := range tests {
+		got, ok, err := InferType(store, tt.desc)
+		if err != nil {
+			t.Fatalf("InferType(%q): %v", tt.desc, err)
+		}
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("InferType(%q): got %q, %v; want %q, %v", tt.desc, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
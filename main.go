@@ -1,7 +1,10 @@
 package main
 
 import (
+	"database/sql"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 
 	tea "charm.land/bubbletea/v2"
@@ -13,37 +16,108 @@ import (
 )
 
 func main() {
-	config, err := config.LoadConfig()
+	flags, args, err := cli.ParseGlobalFlags(os.Args)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	// Profile management works without the profile's database, e.g. when the
+	// default profile's database has been removed
+	if handled, code := cli.RunProfileCommand(flags, args); handled {
+		os.Exit(code)
+	}
+
+	config, err := config.LoadConfig(flags.Profile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error loading config:", err)
 		os.Exit(1)
 	}
-	db, err := database.NewDB(config)
+
+	// CLI: commands that don't open the database (encrypt, decrypt)
+	if handled, code := cli.RunConfigCommand(config, flags, args); handled {
+		os.Exit(code)
+	}
+
+	db, err := openDB(config, flags, len(args) > 1)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error creating database:", err)
+		fmt.Fprintln(os.Stderr, "Error opening database:", err)
 		os.Exit(1)
 	}
-	defer db.Close()
+	// The store is closed explicitly on every exit from here on (see exit)
+	var store database.Store
+	if db != nil {
+		store = database.NewSQLiteStore(db)
+	}
 
-	if err := database.Migrate(db); err != nil {
-		fmt.Fprintln(os.Stderr, "Error running migrations:", err)
-		os.Exit(1)
+	// "sana migrate" inspects and changes the schema itself, so don't migrate first.
+	// A locked (encrypted, no passphrase yet) database is migrated when the TUI unlocks it.
+	if db != nil && !cli.ManagesMigrations(args) {
+		if err := database.Migrate(db); err != nil {
+			fmt.Fprintln(os.Stderr, "Error running migrations:", err)
+			exit(store, 1)
+		}
 	}
 
 	// CLI: if a subcommand was given, run it and exit
-	if handled, code := cli.Run(db, os.Args); handled {
-		os.Exit(code)
+	if handled, code := cli.Run(store, args); handled {
+		exit(store, code)
 	}
 
 	// TUI
 	if isatty.IsTerminal(os.Stdout.Fd()) && os.Getenv("COLORTERM") == "" {
 		os.Setenv("COLORTERM", "truecolor")
 	}
-	m := program.InitialModel(db)
+	m := program.InitialModel(store, config.Profile)
 	p := tea.NewProgram(m)
-	_, err = p.Run()
+	final, err := p.Run()
+	// The TUI may have switched profiles (closing the old database); close the one it ended on
+	var open io.Closer = store
+	if c, ok := final.(io.Closer); ok {
+		open = c
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error running program:", err)
-		os.Exit(1)
+		exit(open, 1)
+	}
+	exit(open, 0)
+}
+
+// exit closes the database, if open, and exits with code. os.Exit skips deferred
+// calls, so this is how every path that opened the database ends; closing can fail,
+// e.g. when saving an encrypted database, which turns a 0 into 1.
+func exit(db io.Closer, code int) {
+	if db != nil {
+		if err := db.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error closing database:", err)
+			if code == 0 {
+				code = 1
+			}
+		}
+	}
+	os.Exit(code)
+}
+
+// openDB opens the profile's database. For an encrypted profile the passphrase comes from
+// -passphrase-fd or SANA_PASSPHRASE; CLI commands otherwise prompt for it, while the TUI
+// starts locked (nil db) and asks for it itself.
+func openDB(cfg *config.Config, flags cli.GlobalFlags, isCommand bool) (*sql.DB, error) {
+	if !cfg.Encrypted {
+		return database.NewDB(cfg)
+	}
+	passphrase, ok, err := cli.Passphrase(flags)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if !isCommand {
+			return nil, nil
+		}
+		if passphrase, err = cli.PromptPassphrase("Passphrase: "); err != nil {
+			return nil, err
+		}
 	}
+	return database.NewEncryptedDB(cfg, passphrase)
 }
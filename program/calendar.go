@@ -0,0 +1,82 @@
+package program
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+// The calendar overlay shows the active month like a contribution graph: one row per
+// weekday (Monday first), one column per week, each day shaded by how much was spent.
+
+// numHeatLevels is the number of shades a day can have; level 0 is a day without expenses.
+const numHeatLevels = 5
+
+// daysInMonth returns the number of days in the month containing t.
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// weekdayRow returns the calendar row of a weekday: 0 for Monday through 6 for Sunday.
+func weekdayRow(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
+
+// calendarCell returns the row (weekday) and column (week) of day in the month containing t.
+func calendarCell(t time.Time, day int) (row, col int) {
+	first := weekdayRow(time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).Weekday())
+	return (first + day - 1) % 7, (first + day - 1) / 7
+}
+
+// calendarWeeks returns the number of week columns the month containing t spans.
+func calendarWeeks(t time.Time) int {
+	_, col := calendarCell(t, daysInMonth(t))
+	return col + 1
+}
+
+// heatLevel returns the shade of a day that spent total, relative to the month's busiest day:
+// 0 when nothing was spent, otherwise 1 (up to a quarter of highest) to 4.
+func heatLevel(total, highest float64) int {
+	if total <= 0 || highest <= 0 {
+		return 0
+	}
+	level := int(math.Ceil(total / highest * (numHeatLevels - 1)))
+	return max(1, min(level, numHeatLevels-1))
+}
+
+// dailyTotalsByDay indexes daily totals by day of the month and returns the highest total.
+func dailyTotalsByDay(daily []types.DailyTotal) (map[int]types.DailyTotal, float64) {
+	byDay := make(map[int]types.DailyTotal, len(daily))
+	var highest float64
+	for _, d := range daily {
+		byDay[d.Day.Day()] = d
+		highest = max(highest, d.Total)
+	}
+	return byDay, highest
+}
+
+// withDayTerm returns the filter query with its day term (if any) replaced by d:day.
+func withDayTerm(query string, day int) string {
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		if !strings.HasPrefix(strings.ToLower(term), "d:") {
+			terms = append(terms, term)
+		}
+	}
+	return strings.Join(append(terms, "d:"+strconv.Itoa(day)), " ")
+}
+
+// moveCalendarDay moves the calendar selection by delta days, staying within the active month.
+func (m *model) moveCalendarDay(delta int) {
+	m.ui.calendarDay = max(1, min(m.ui.calendarDay+delta, daysInMonth(m.ui.activeMonth)))
+}
+
+// filterToDay narrows the expenses box to one day of the active month, keeping the other filter terms.
+func (m *model) filterToDay(day int) {
+	query := withDayTerm(m.ui.filterQuery, day)
+	m.setFilterQuery(query)
+	m.ui.filterInput.SetValue(query)
+}
@@ -0,0 +1,141 @@
+package program
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestCalendarCell(t *testing.T) {
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local) // starts on a Saturday
+	tests := []struct {
+		day      int
+		row, col int
+	}{
+		{1, 5, 0},
+		{2, 6, 0},
+		{3, 0, 1},
+		{15, 5, 2},
+		{31, 0, 5},
+	}
+	for _, tt := range tests {
+		row, col := calendarCell(march, tt.day)
+		if row != tt.row || col != tt.col {
+			t.Errorf("calendarCell(2025-03, %d): got (%d, %d), want (%d, %d)", tt.day, row, col, tt.row, tt.col)
+		}
+	}
+
+	weeks := []struct {
+		month time.Time
+		want  int
+	}{
+		{march, 6},
+		{time.Date(2021, 2, 1, 0, 0, 0, 0, time.Local), 4}, // Monday 1st, 28 days
+		{time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local), 5},
+	}
+	for _, tt := range weeks {
+		if got := calendarWeeks(tt.month); got != tt.want {
+			t.Errorf("calendarWeeks(%s): got %d, want %d", tt.month.Format("2006-01"), got, tt.want)
+		}
+	}
+}
+
+func TestHeatLevel(t *testing.T) {
+	tests := []struct {
+		total, highest float64
+		want           int
+	}{
+		{0, 100, 0},
+		{10, 0, 0},
+		{1, 100, 1},
+		{25, 100, 1},
+		{26, 100, 2},
+		{75, 100, 3},
+		{100, 100, 4},
+	}
+	for _, tt := range tests {
+		if got := heatLevel(tt.total, tt.highest); got != tt.want {
+			t.Errorf("heatLevel(%.0f, %.0f): got %d, want %d", tt.total, tt.highest, got, tt.want)
+		}
+	}
+}
+
+func TestWithDayTerm(t *testing.T) {
+	tests := []struct {
+		query string
+		day   int
+		want  string
+	}{
+		{"", 5, "d:5"},
+		{"coffee @food", 12, "coffee @food d:12"},
+		{"D:3 >10", 4, ">10 d:4"},
+	}
+	for _, tt := range tests {
+		if got := withDayTerm(tt.query, tt.day); got != tt.want {
+			t.Errorf("withDayTerm(%q, %d): got %q, want %q", tt.query, tt.day, got, tt.want)
+		}
+	}
+}
+
+func TestCalendarOverlay(t *testing.T) {
+	store := database.NewMemoryStore()
+	date := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	for i, amount := range []float64{10, 20, 5} {
+		if _, err := store.CreateExpense(date.AddDate(0, 0, -i), amount, "", types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = startOfMonth(date)
+	updated, _ := m.Update(loadMonthData(store, date)())
+	m = updated.(model)
+	press := func(k tea.KeyPressMsg) {
+		updated, _ := m.Update(k)
+		m = updated.(model)
+	}
+
+	if len(m.data.daily) != 3 {
+		t.Fatalf("daily totals: got %+v, want 3 days", m.data.daily)
+	}
+	press(tea.KeyPressMsg{Code: 'C', Text: "C"})
+	if m.ui.overlay != overlayCalendar || m.ui.calendarDay != 1 {
+		t.Fatalf("C: overlay %v on day %d, want calendar on day 1", m.ui.overlay, m.ui.calendarDay)
+	}
+
+	// l moves a week, j a day; the selection stays within the month
+	press(tea.KeyPressMsg{Code: 'l', Text: "l"})
+	press(tea.KeyPressMsg{Code: 'l', Text: "l"})
+	press(tea.KeyPressMsg{Code: 'k', Text: "k"})
+	if m.ui.calendarDay != 14 {
+		t.Errorf("l l k: got day %d, want 14", m.ui.calendarDay)
+	}
+	for range 10 {
+		press(tea.KeyPressMsg{Code: 'l', Text: "l"})
+	}
+	if m.ui.calendarDay != 31 {
+		t.Errorf("l past the end: got day %d, want 31", m.ui.calendarDay)
+	}
+
+	// enter filters the expenses box to the day
+	press(tea.KeyPressMsg{Code: 'g', Text: "g"})
+	for range 13 {
+		press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if m.ui.overlay != overlayNone || m.ui.selected != expensesBox {
+		t.Fatalf("enter: overlay %v, selected %v; want closed on the expenses box", m.ui.overlay, m.ui.selected)
+	}
+	if m.ui.filterQuery != "d:14" || len(m.data.expenses) != 1 || m.data.expenses[0].Amount != 20 {
+		t.Errorf("enter: filter %q shows %+v, want d:14 with the 20.00 expense", m.ui.filterQuery, m.data.expenses)
+	}
+
+	// Reopening starts on the filtered day
+	press(tea.KeyPressMsg{Code: 'C', Text: "C"})
+	if m.ui.calendarDay != 14 {
+		t.Errorf("reopen: got day %d, want 14", m.ui.calendarDay)
+	}
+}
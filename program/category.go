@@ -0,0 +1,96 @@
+package program
+
+import (
+	"slices"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kyawphyothu/sana/types"
+)
+
+// The category detail overlay lists the expenses of one category with their own
+// selection. It remembers the category by name, so a reload (after a delete) keeps
+// showing the same category even when the summary rows move.
+
+// categoryExpenses returns the expenses of the category detail overlay.
+func (m model) categoryExpenses() []types.Expense {
+	return m.filterExpensesByCategory(m.ui.detailCategory)
+}
+
+// categoryStats returns the subtotal, number and average amount of expenses.
+func categoryStats(expenses []types.Expense) (subtotal float64, count int, average float64) {
+	for _, e := range expenses {
+		subtotal += e.Amount
+	}
+	if len(expenses) > 0 {
+		average = subtotal / float64(len(expenses))
+	}
+	return subtotal, len(expenses), average
+}
+
+// categoryDetailMaxRows returns how many expenses the category detail overlay shows at once.
+func (m model) categoryDetailMaxRows() int {
+	return max(1, min(overlayMaxRows, m.ui.height-overlayHeaderRows-1))
+}
+
+// selectedCategoryExpense returns the selected expense of the category detail overlay.
+func (m model) selectedCategoryExpense() (types.Expense, bool) {
+	expenses := m.categoryExpenses()
+	i := m.ui.categoryDetailList.SelectedRow()
+	if i < 0 || i >= len(expenses) {
+		return types.Expense{}, false
+	}
+	return expenses[i], true
+}
+
+// openCategoryDetail shows the expenses of the selected summary row.
+func (m model) openCategoryDetail() (tea.Model, tea.Cmd) {
+	i := m.ui.summaryList.SelectedRow()
+	if i < 0 || i >= len(m.data.summary) {
+		return m, nil
+	}
+	m.ui.detailCategory = m.data.summary[i].Category
+	m.ui.categoryDetailList.reset()
+	m.ui.categoryDetailList.SetLength(len(m.categoryExpenses()))
+	m.ui.err = nil
+	m.ui.selected = categoryDetailOverlay
+	m.ui.overlay = overlayCategoryDetail
+	return m, nil
+}
+
+// moveDetailCategory switches the category detail overlay to the next (delta 1) or
+// previous (delta -1) category of the summary, moving the summary selection along.
+func (m *model) moveDetailCategory(delta int) {
+	i := slices.IndexFunc(m.data.summary, func(s types.CategorySummary) bool {
+		return s.Category == m.ui.detailCategory
+	})
+	next := i + delta
+	if i < 0 || next < 0 || next >= len(m.data.summary) {
+		return
+	}
+	m.ui.detailCategory = m.data.summary[next].Category
+	m.ui.summaryList.SetLength(len(m.data.summary))
+	m.ui.summaryList.selectRow(next, m.calculateMaxVisibleRows())
+	m.ui.categoryDetailList.reset()
+	m.ui.categoryDetailList.SetLength(len(m.categoryExpenses()))
+}
+
+// closeCategoryDetail closes the category detail overlay, back to the summary box.
+func (m *model) closeCategoryDetail() {
+	m.ui.detailCategory = ""
+	m.ui.selected = summaryBox
+	m.ui.overlay = overlayNone
+	m.ui.err = nil
+}
+
+// closeExpenseOverlay closes the expense detail or confirm delete overlay, back to
+// the category detail overlay it was opened from, if any, or else the expenses box.
+func (m *model) closeExpenseOverlay() {
+	m.ui.deleteID = 0
+	if m.ui.detailCategory != "" {
+		m.ui.selected = categoryDetailOverlay
+		m.ui.overlay = overlayCategoryDetail
+		return
+	}
+	m.ui.selected = expensesBox
+	m.ui.overlay = overlayNone
+}
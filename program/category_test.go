@@ -0,0 +1,100 @@
+package program
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestCategoryStats(t *testing.T) {
+	subtotal, count, average := categoryStats([]types.Expense{{Amount: 10}, {Amount: 20}, {Amount: 30}})
+	if subtotal != 60 || count != 3 || average != 20 {
+		t.Errorf("categoryStats: got (%.2f, %d, %.2f), want (60.00, 3, 20.00)", subtotal, count, average)
+	}
+	if _, _, average := categoryStats(nil); average != 0 {
+		t.Errorf("categoryStats(nil): got average %.2f, want 0", average)
+	}
+}
+
+func TestCategoryDetailOverlay(t *testing.T) {
+	store := database.NewMemoryStore()
+	date := time.Date(2025, 3, 20, 12, 0, 0, 0, time.Local)
+	// 20 food expenses (the largest category, so the first summary row) and one bill
+	for i := range 20 {
+		if _, err := store.CreateExpense(date.Add(-time.Duration(i)*time.Hour), 10, "", types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	if _, err := store.CreateExpense(date, 5, "", types.ExpenseTypeBills); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = startOfMonth(date)
+	load := func() {
+		updated, _ := m.Update(loadMonthData(store, date)())
+		m = updated.(model)
+	}
+	press := func(k tea.KeyPressMsg) tea.Cmd {
+		updated, cmd := m.Update(k)
+		m = updated.(model)
+		return cmd
+	}
+	load()
+
+	press(tea.KeyPressMsg{Code: 's', Text: "s"})
+	press(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	if m.ui.overlay != overlayCategoryDetail || m.ui.detailCategory != "Food" {
+		t.Fatalf("space: overlay %v on %q, want category detail on Food", m.ui.overlay, m.ui.detailCategory)
+	}
+
+	// The overlay scrolls through all 20 expenses, not just the first screenful
+	press(tea.KeyPressMsg{Code: 'G', Text: "G"})
+	if got := m.ui.categoryDetailList.SelectedRow(); got != 19 || m.ui.categoryDetailList.ScrollOffset() == 0 {
+		t.Errorf("G: got row %d offset %d, want row 19 scrolled", got, m.ui.categoryDetailList.ScrollOffset())
+	}
+
+	// enter shows the expense, and closing it goes back to the overlay
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	selected, _ := m.selectedCategoryExpense()
+	if m.ui.overlay != overlayExpenseDetail || m.ui.detailExpense.ID != selected.ID {
+		t.Fatalf("enter: overlay %v on %d, want expense detail on %d", m.ui.overlay, m.ui.detailExpense.ID, selected.ID)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m.ui.overlay != overlayCategoryDetail {
+		t.Fatalf("esc in detail: overlay %v, want category detail", m.ui.overlay)
+	}
+
+	// d deletes the selected row of the overlay, not the expenses box's selection
+	press(tea.KeyPressMsg{Code: 'd', Text: "d"})
+	if targets := m.deleteTargets(); m.ui.overlay != overlayConfirmDelete || len(targets) != 1 || targets[0].ID != selected.ID {
+		t.Fatalf("d: overlay %v targets %+v, want confirm delete of %d", m.ui.overlay, targets, selected.ID)
+	}
+	msg := press(tea.KeyPressMsg{Code: tea.KeyEnter})()
+	updated, _ := m.Update(msg)
+	m = updated.(model)
+	load()
+	if m.ui.overlay != overlayCategoryDetail || len(m.categoryExpenses()) != 19 || m.ui.categoryDetailList.SelectedRow() != 18 {
+		t.Fatalf("after delete: overlay %v, %d expenses, row %d; want category detail, 19, row 18",
+			m.ui.overlay, len(m.categoryExpenses()), m.ui.categoryDetailList.SelectedRow())
+	}
+
+	// l and h move through the categories, with the summary selection
+	press(tea.KeyPressMsg{Code: 'l', Text: "l"})
+	if m.ui.detailCategory != "Bills" || m.ui.summaryList.SelectedRow() != 1 || m.ui.categoryDetailList.SelectedRow() != 0 {
+		t.Errorf("l: got %q with summary row %d, want Bills on row 1", m.ui.detailCategory, m.ui.summaryList.SelectedRow())
+	}
+	press(tea.KeyPressMsg{Code: 'l', Text: "l"})
+	press(tea.KeyPressMsg{Code: 'h', Text: "h"})
+	if m.ui.detailCategory != "Food" {
+		t.Errorf("l h: got %q, want Food", m.ui.detailCategory)
+	}
+
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m.ui.overlay != overlayNone || m.ui.selected != summaryBox || m.ui.detailCategory != "" {
+		t.Errorf("esc: overlay %v selected %v, want the summary box", m.ui.overlay, m.ui.selected)
+	}
+}
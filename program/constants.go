@@ -3,28 +3,46 @@ package program
 // UI Layout Constants
 
 const (
-	// Terminal minimum dimensions
+	// Terminal minimum dimensions; smaller terminals get the compact layout
 	minWidth  = 70
 	minHeight = 20
 
+	// Compact layout: one box at a time under a tab bar, down to this size
+	compactMinWidth       = 40
+	compactMinHeight      = 12
+	compactTabBarHeight   = 1
+	tableCompactDateWidth = 12 // "2006-01-02 ●"
+	tableCompactAmtWidth  = 9  // "10,000.00"
+
 	// Title box dimensions
 	titleBoxHeight = 5
 
+	// Status bar at the bottom of the screen: messages, key hints and the month shown
+	statusBarHeight    = 1
+	statusMinLeftWidth = 40 // narrower, the bar leaves out the month and counts
+
 	// Table column widths
-	tableDateWidth          = 21
-	tableCategoryWidth      = 14 // must fit "Personal Care" and "Entertainment" (13 chars)
-	tableAmountWidth        = 12
-	tableAmountWidthSummary = 15
-	tableCountWidth         = 5
-	tableMinDescWidth       = 10
-	tableMinCategoryWidth   = 10
+	tableDateWidth        = 21
+	tableCategoryWidth    = 14 // must fit "Personal Care" and "Entertainment" (13 chars)
+	tableAmountWidth      = 12
+	tableCountWidth       = 5
+	tablePercentWidth     = 6 // "100.0%"
+	tableMinShareBarWidth = 4
+	tableMinDescWidth     = 10
+	tableMinCategoryWidth = 10
 
 	// Table spacing
 	tableColumnSpacing      = 2
 	tableColumnGapsExpenses = 3 // Number of gaps between 4 columns
-	tableColumnGapsSummary  = 2 // Number of gaps between 3 columns
+	tableColumnGapsSummary  = 3 // Number of gaps between 4 columns (plus one more for the share bar)
 	tableColumnGapsOverlay  = 2 // Number of gaps between 3 columns
 
+	// Add form: how many recent descriptions are suggested
+	descriptionSuggestionLimit = 200
+
+	// Vertical bar chart: a two-cell bar and a gap per month
+	monthlyReportColumnWidth = 3
+
 	// Table padding (borders + padding)
 	tableBorderPadding = 4
 
@@ -49,6 +67,29 @@ const (
 	confirmDeleteOverlayWidth  = 50
 	confirmDeleteOverlayHeight = 10
 
+	// Overlay dimensions (profile switcher)
+	profileOverlayWidth     = 40
+	profileOverlayExtraRows = 4 // blank line + help + borders
+
+	// Overlay dimensions (go to month)
+	goToMonthOverlayWidth  = 40
+	goToMonthOverlayHeight = 5 // input + blank line + help + borders
+	monthInputWidth        = 10
+
+	// Overlay dimensions (quick add)
+	quickAddOverlayWidth  = 60
+	quickAddOverlayHeight = 10 // input + blank line + 4 preview lines + blank line + help + borders
+	quickAddInputWidth    = 50
+	quickAddPreviewLines  = 4
+
+	// Overlay dimensions (expense detail)
+	expenseDetailOverlayWidth = 60
+	expenseDetailLabelWidth   = 13 // "Description: "
+
+	// Overlay dimensions (calendar)
+	calendarOverlayWidth = 52
+	calendarLabelWidth   = 4 // "Mon "
+
 	// Form dimensions
 	formWidth          = 30
 	promptWidth        = 13
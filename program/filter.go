@@ -0,0 +1,231 @@
+package program
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+// expenseFilter narrows the expenses box. It is parsed from a query of space-separated terms:
+//
+//	coffee     description contains "coffee" (every text term must match)
+//	@food      category starts with "food" (any of several @ terms)
+//	>50 <=20   amount compared to a number (also >=, <, =)
+//	10..50     amount between 10 and 50, inclusive
+//	d:15       expenses on day 15 of the month
+type expenseFilter struct {
+	text       []string
+	categories []string
+	minAmount  float64 // inclusive, when hasMin
+	maxAmount  float64 // inclusive, when hasMax
+	hasMin     bool
+	hasMax     bool
+	day        int // 0 when unset
+}
+
+// parseExpenseFilter parses a filter query. Terms are matched case-insensitively.
+func parseExpenseFilter(query string) (expenseFilter, error) {
+	var f expenseFilter
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		switch {
+		case strings.HasPrefix(term, "@"):
+			if len(term) == 1 {
+				return f, fmt.Errorf("missing category after @")
+			}
+			f.categories = append(f.categories, term[1:])
+		case strings.HasPrefix(term, "d:"):
+			day, err := strconv.Atoi(term[2:])
+			if err != nil || day < 1 || day > 31 {
+				return f, fmt.Errorf("invalid day %q: use d:1 to d:31", term[2:])
+			}
+			f.day = day
+		case strings.ContainsAny(term[:1], "<>="):
+			if err := f.parseAmountComparison(term); err != nil {
+				return f, err
+			}
+		case strings.Contains(term, ".."):
+			from, to, _ := strings.Cut(term, "..")
+			lo, errLo := strconv.ParseFloat(from, 64)
+			hi, errHi := strconv.ParseFloat(to, 64)
+			if errLo != nil || errHi != nil || lo > hi {
+				return f, fmt.Errorf("invalid amount range %q: use e.g. 10..50", term)
+			}
+			f.atLeast(lo)
+			f.atMost(hi)
+		default:
+			f.text = append(f.text, term)
+		}
+	}
+	return f, nil
+}
+
+// parseAmountComparison applies an amount term such as ">50" or "<=20" to f.
+func (f *expenseFilter) parseAmountComparison(term string) error {
+	op := term[:1]
+	if len(term) > 1 && term[1] == '=' {
+		op = term[:2]
+	}
+	n, err := strconv.ParseFloat(term[len(op):], 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount in %q: use e.g. >50", term)
+	}
+	switch op {
+	case ">":
+		f.atLeast(math.Nextafter(n, math.Inf(1)))
+	case ">=":
+		f.atLeast(n)
+	case "<":
+		f.atMost(math.Nextafter(n, math.Inf(-1)))
+	case "<=":
+		f.atMost(n)
+	case "=":
+		f.atLeast(n)
+		f.atMost(n)
+	default:
+		return fmt.Errorf("invalid amount comparison %q: use >, >=, <, <= or =", op)
+	}
+	return nil
+}
+
+// atLeast narrows the filter to amounts >= n.
+func (f *expenseFilter) atLeast(n float64) {
+	if !f.hasMin || n > f.minAmount {
+		f.minAmount, f.hasMin = n, true
+	}
+}
+
+// atMost narrows the filter to amounts <= n.
+func (f *expenseFilter) atMost(n float64) {
+	if !f.hasMax || n < f.maxAmount {
+		f.maxAmount, f.hasMax = n, true
+	}
+}
+
+// isEmpty reports whether the filter lets every expense through.
+func (f expenseFilter) isEmpty() bool {
+	return len(f.text) == 0 && len(f.categories) == 0 && f.day == 0 && !f.hasMin && !f.hasMax
+}
+
+// matches reports whether e passes the filter.
+func (f expenseFilter) matches(e types.Expense) bool {
+	if (f.hasMin && e.Amount < f.minAmount) || (f.hasMax && e.Amount > f.maxAmount) {
+		return false
+	}
+	if f.day != 0 && e.Date.Day() != f.day {
+		return false
+	}
+	desc := strings.ToLower(e.Description)
+	for _, t := range f.text {
+		if !strings.Contains(desc, t) {
+			return false
+		}
+	}
+	if len(f.categories) == 0 {
+		return true
+	}
+	category := strings.ToLower(e.Type.String())
+	for _, c := range f.categories {
+		if strings.HasPrefix(category, c) || strings.HasPrefix(string(e.Type), c) {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeExpenses groups expenses by category like the store's summary:
+// highest total first, then highest count.
+func summarizeExpenses(expenses []types.Expense) ([]types.CategorySummary, float64) {
+	byCategory := map[string]int{}
+	summary := []types.CategorySummary{}
+	var total float64
+	for _, e := range expenses {
+		category := e.Type.String()
+		i, ok := byCategory[category]
+		if !ok {
+			i = len(summary)
+			byCategory[category] = i
+			summary = append(summary, types.CategorySummary{Category: category})
+		}
+		summary[i].Count++
+		summary[i].Total += e.Amount
+		total += e.Amount
+	}
+	slices.SortStableFunc(summary, func(a, b types.CategorySummary) int {
+		if c := cmp.Compare(b.Total, a.Total); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Count, a.Count)
+	})
+	return summary, total
+}
+
+// applyFilter derives the shown expenses, summary and total from the loaded month
+// and the active filter, keeping the selected expense selected if it still matches.
+// In grouped mode the shown expenses are ordered by day (newest first unless sorted
+// by date ascending), keeping the sort within each day.
+func (m *model) applyFilter() {
+	selectedID := m.selectedExpenseID()
+
+	if m.ui.filter.isEmpty() {
+		m.data.expenses = slices.Clone(m.data.allExpenses)
+		m.data.summary = m.data.allSummary
+		m.data.total = m.data.allTotal
+	} else {
+		m.data.expenses = []types.Expense{}
+		for _, e := range m.data.allExpenses {
+			if m.ui.filter.matches(e) {
+				m.data.expenses = append(m.data.expenses, e)
+			}
+		}
+		m.data.summary, m.data.total = summarizeExpenses(m.data.expenses)
+	}
+	if m.ui.groupByDay {
+		groupExpensesByDay(m.data.expenses, m.ui.expenseSort != expenseSort{key: sortByDate, asc: true})
+	}
+
+	m.clampSelections()
+	if selectedID != 0 {
+		m.selectExpenseByID(selectedID)
+	}
+}
+
+// setFilterQuery parses query and, if valid, applies it. An invalid query keeps
+// the previous filter and reports the problem in the filter bar.
+func (m *model) setFilterQuery(query string) {
+	f, err := parseExpenseFilter(query)
+	m.ui.filterErr = err
+	if err != nil {
+		return
+	}
+	m.ui.filter = f
+	m.ui.filterQuery = strings.TrimSpace(query)
+	m.applyFilter()
+}
+
+// keepFilter stops editing the filter bar, keeping the filter applied.
+func (m *model) keepFilter() {
+	m.ui.filtering = false
+	m.ui.filterInput.Blur()
+	m.ui.filterErr = nil
+}
+
+// clearFilter removes the expenses filter and closes the filter bar.
+func (m *model) clearFilter() {
+	m.ui.filtering = false
+	m.ui.filterInput.Blur()
+	m.ui.filterInput.SetValue("")
+	m.ui.filterErr = nil
+	m.ui.filter = expenseFilter{}
+	m.ui.filterQuery = ""
+	m.applyFilter()
+}
+
+// showFilterBar reports whether the expenses box reserves a row for the filter bar.
+func (m model) showFilterBar() bool {
+	return m.ui.filtering || !m.ui.filter.isEmpty()
+}
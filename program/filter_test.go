@@ -0,0 +1,68 @@
+package program
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestExpenseFilter(t *testing.T) {
+	coffee := types.Expense{Date: time.Date(2025, 3, 15, 9, 0, 0, 0, time.Local), Amount: 4.5, Description: "Morning coffee", Type: types.ExpenseTypeFood}
+	rent := types.Expense{Date: time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local), Amount: 800, Description: "Rent", Type: types.ExpenseTypeBills}
+	tests := []struct {
+		query       string
+		coffee      bool
+		rent        bool
+		errExpected bool
+	}{
+		{"", true, true, false},
+		{"COFFEE", true, false, false},
+		{"morning tea", false, false, false},
+		{"@fo", true, false, false},
+		{"@food @bills", true, true, false},
+		{">50", false, true, false},
+		{">=4.5 <=4.5", true, false, false},
+		{"=800", false, true, false},
+		{"1..10", true, false, false},
+		{"d:1", false, true, false},
+		{"d:15 @food >1", true, false, false},
+		{">", false, false, true},
+		{"50..10", false, false, true},
+		{"d:32", false, false, true},
+		{"@", false, false, true},
+	}
+	for _, tt := range tests {
+		f, err := parseExpenseFilter(tt.query)
+		if (err != nil) != tt.errExpected {
+			t.Errorf("parseExpenseFilter(%q): err = %v, want error %v", tt.query, err, tt.errExpected)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := f.matches(coffee); got != tt.coffee {
+			t.Errorf("%q matches coffee: got %v, want %v", tt.query, got, tt.coffee)
+		}
+		if got := f.matches(rent); got != tt.rent {
+			t.Errorf("%q matches rent: got %v, want %v", tt.query, got, tt.rent)
+		}
+		if got := f.isEmpty(); got != (tt.query == "") {
+			t.Errorf("%q isEmpty: got %v", tt.query, got)
+		}
+	}
+}
+
+func TestSummarizeExpenses(t *testing.T) {
+	summary, total := summarizeExpenses([]types.Expense{
+		{Amount: 5, Type: types.ExpenseTypeFood},
+		{Amount: 20, Type: types.ExpenseTypeBills},
+		{Amount: 10, Type: types.ExpenseTypeFood},
+	})
+	if total != 35 {
+		t.Errorf("summarizeExpenses total: got %.2f, want 35", total)
+	}
+	if len(summary) != 2 || summary[0].Category != "Bills" || summary[1].Count != 2 || summary[1].Total != 15 {
+		t.Errorf("summarizeExpenses: got %+v, want Bills 20 then Food 2x15", summary)
+	}
+}
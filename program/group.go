@@ -0,0 +1,163 @@
+package program
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+// In grouped mode the expenses box starts each day with a header row showing the
+// weekday and the day's total. The list's rows are then no longer the expenses
+// themselves, so code working on the selected expense goes through expenseRows.
+
+// expenseRow is one row of the expenses box: an expense, or in grouped mode a day header.
+// index is the expense on the row; for a header it is the first expense of the day.
+type expenseRow struct {
+	index  int
+	header bool
+	date   time.Time // headers only
+	total  float64   // headers only
+	count  int       // headers only
+}
+
+// dayKey identifies the calendar day of t, for grouping and remembering collapsed days.
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// groupExpensesByDay orders expenses so each day's expenses are together, keeping
+// their order within the day.
+func groupExpensesByDay(expenses []types.Expense, newestFirst bool) {
+	slices.SortStableFunc(expenses, func(a, b types.Expense) int {
+		c := strings.Compare(dayKey(a.Date), dayKey(b.Date))
+		if newestFirst {
+			c = -c
+		}
+		return c
+	})
+}
+
+// expenseRows returns the rows of the expenses box. In grouped mode the expenses of
+// collapsed days are left out and only their header is shown.
+func (m model) expenseRows() []expenseRow {
+	rows := make([]expenseRow, 0, len(m.data.expenses))
+	if !m.ui.groupByDay {
+		for i := range m.data.expenses {
+			rows = append(rows, expenseRow{index: i})
+		}
+		return rows
+	}
+	header := -1
+	for i, e := range m.data.expenses {
+		if header < 0 || dayKey(rows[header].date) != dayKey(e.Date) {
+			header = len(rows)
+			rows = append(rows, expenseRow{index: i, header: true, date: e.Date})
+		}
+		rows[header].total += e.Amount
+		rows[header].count++
+		if !m.ui.collapsedDays[dayKey(e.Date)] {
+			rows = append(rows, expenseRow{index: i})
+		}
+	}
+	return rows
+}
+
+// selectedExpenseRow returns the selected row of the expenses box.
+func (m model) selectedExpenseRow() (expenseRow, bool) {
+	rows := m.expenseRows()
+	i := m.ui.expensesList.SelectedRow()
+	if i < 0 || i >= len(rows) {
+		return expenseRow{}, false
+	}
+	return rows[i], true
+}
+
+// selectedExpenseIndex returns the index of the selected expense, or -1 when
+// nothing or a day header is selected.
+func (m model) selectedExpenseIndex() int {
+	row, ok := m.selectedExpenseRow()
+	if !ok || row.header {
+		return -1
+	}
+	return row.index
+}
+
+// selectedExpenseID returns the ID of the selected expense, or of the first expense
+// of the selected day header; 0 when nothing is selected.
+func (m model) selectedExpenseID() int64 {
+	row, ok := m.selectedExpenseRow()
+	if !ok {
+		return 0
+	}
+	return m.data.expenses[row.index].ID
+}
+
+// expenseRowIndex returns the row showing expense i, or its day's header when the
+// day is collapsed; -1 if the expense isn't shown.
+func (m model) expenseRowIndex(i int) int {
+	header := -1
+	for r, row := range m.expenseRows() {
+		switch {
+		case !row.header && row.index == i:
+			return r
+		case row.header && dayKey(row.date) == dayKey(m.data.expenses[i].Date):
+			header = r
+		}
+	}
+	return header
+}
+
+// toggleGroupByDay switches between the flat and the day-grouped expenses list,
+// keeping the selected expense selected.
+func (m *model) toggleGroupByDay() {
+	selectedID := m.selectedExpenseID()
+	m.ui.groupByDay = !m.ui.groupByDay
+	m.applyFilter()
+	if selectedID != 0 {
+		m.selectExpenseByID(selectedID)
+	}
+}
+
+// toggleDayCollapsed collapses or expands the day of the selected header.
+func (m *model) toggleDayCollapsed(date time.Time) {
+	key := dayKey(date)
+	if m.ui.collapsedDays == nil {
+		m.ui.collapsedDays = map[string]bool{}
+	}
+	if m.ui.collapsedDays[key] {
+		delete(m.ui.collapsedDays, key)
+	} else {
+		m.ui.collapsedDays[key] = true
+	}
+	m.ui.expensesList.SetLength(len(m.expenseRows()))
+	m.clampSelections()
+}
+
+// toggleAllDaysCollapsed collapses every shown day, or expands them all when they
+// already are. The selection follows the selected expense to its day's header.
+func (m *model) toggleAllDaysCollapsed() {
+	selectedID := m.selectedExpenseID()
+	allCollapsed := true
+	for _, e := range m.data.expenses {
+		if !m.ui.collapsedDays[dayKey(e.Date)] {
+			allCollapsed = false
+			break
+		}
+	}
+	if allCollapsed {
+		m.ui.collapsedDays = nil
+	} else {
+		if m.ui.collapsedDays == nil {
+			m.ui.collapsedDays = map[string]bool{}
+		}
+		for _, e := range m.data.expenses {
+			m.ui.collapsedDays[dayKey(e.Date)] = true
+		}
+	}
+	m.clampSelections()
+	if selectedID != 0 {
+		m.selectExpenseByID(selectedID)
+	}
+}
@@ -0,0 +1,122 @@
+package program
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestGroupExpensesByDay(t *testing.T) {
+	at := func(d, h int) time.Time { return time.Date(2025, 3, d, h, 0, 0, 0, time.Local) }
+	// Sorted by amount: days are interleaved
+	expenses := []types.Expense{
+		{ID: 1, Date: at(2, 9), Amount: 50},
+		{ID: 2, Date: at(1, 9), Amount: 40},
+		{ID: 3, Date: at(2, 18), Amount: 30},
+		{ID: 4, Date: at(1, 18), Amount: 20},
+	}
+	groupExpensesByDay(expenses, true)
+	want := []int64{1, 3, 2, 4}
+	for i, e := range expenses {
+		if e.ID != want[i] {
+			t.Fatalf("groupExpensesByDay(newest first): got %v at %d, want order %v", e.ID, i, want)
+		}
+	}
+	groupExpensesByDay(expenses, false)
+	want = []int64{2, 4, 1, 3}
+	for i, e := range expenses {
+		if e.ID != want[i] {
+			t.Fatalf("groupExpensesByDay(oldest first): got %v at %d, want order %v", e.ID, i, want)
+		}
+	}
+}
+
+func TestExpenseRows(t *testing.T) {
+	at := func(d, h int) time.Time { return time.Date(2025, 3, d, h, 0, 0, 0, time.Local) }
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.data.expenses = []types.Expense{
+		{ID: 1, Date: at(2, 18), Amount: 5},
+		{ID: 2, Date: at(2, 9), Amount: 7},
+		{ID: 3, Date: at(1, 12), Amount: 3},
+	}
+
+	if rows := m.expenseRows(); len(rows) != 3 || rows[0].header {
+		t.Errorf("expenseRows (flat): got %+v, want 3 expense rows", rows)
+	}
+
+	m.ui.groupByDay = true
+	rows := m.expenseRows()
+	if len(rows) != 5 || !rows[0].header || rows[0].total != 12 || rows[0].count != 2 || !rows[3].header || rows[4].index != 2 {
+		t.Fatalf("expenseRows (grouped): got %+v, want header(12, 2) 1 2 header 3", rows)
+	}
+
+	m.ui.collapsedDays = map[string]bool{"2025-03-02": true}
+	rows = m.expenseRows()
+	if len(rows) != 3 || !rows[0].header || !rows[1].header || rows[2].index != 2 {
+		t.Errorf("expenseRows (collapsed): got %+v, want header header 3", rows)
+	}
+	if got := m.expenseRowIndex(1); got != 0 {
+		t.Errorf("expenseRowIndex of a collapsed expense: got %d, want its header (0)", got)
+	}
+}
+
+func TestGroupedExpensesNavigation(t *testing.T) {
+	store := database.NewMemoryStore()
+	date := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	for i, d := range []int{0, 0, -1} {
+		if _, err := store.CreateExpense(date.AddDate(0, 0, d).Add(time.Duration(i)*time.Minute), float64(10*(i+1)), "", types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = startOfMonth(date)
+	updated, _ := m.Update(loadMonthData(store, date)())
+	m = updated.(model)
+	press := func(k tea.KeyPressMsg) {
+		updated, _ := m.Update(k)
+		m = updated.(model)
+	}
+
+	// Grouping keeps the selected expense selected, below its day's header
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	selected := m.data.expenses[m.selectedExpenseIndex()].ID
+	press(tea.KeyPressMsg{Code: 'z', Text: "z"})
+	if i := m.selectedExpenseIndex(); i < 0 || m.data.expenses[i].ID != selected || m.ui.expensesList.SelectedRow() != 2 {
+		t.Fatalf("z: selected row %d, want expense %d on row 2", m.ui.expensesList.SelectedRow(), selected)
+	}
+
+	// Headers are rows too: nothing to delete or mark there, enter collapses the day
+	press(tea.KeyPressMsg{Code: 'g', Text: "g"})
+	if len(m.bulkTargets()) != 0 {
+		t.Errorf("on header: got %d delete targets, want 0", len(m.bulkTargets()))
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if m.ui.overlay != overlayNone || len(m.expenseRows()) != 3 {
+		t.Fatalf("enter on header: overlay %v, %d rows; want collapsed day and 3 rows", m.ui.overlay, len(m.expenseRows()))
+	}
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	if i := m.selectedExpenseIndex(); i < 0 || m.data.expenses[i].Amount != 30 {
+		t.Errorf("j j past a collapsed day: selected %d, want the 30.00 expense", i)
+	}
+	press(tea.KeyPressMsg{Code: 'G', Text: "G"})
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if m.ui.overlay != overlayExpenseDetail {
+		t.Errorf("enter on expense: overlay %v, want expense detail", m.ui.overlay)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+
+	// Z collapses every day, then expands them again
+	press(tea.KeyPressMsg{Code: 'Z', Text: "Z"})
+	if rows := m.expenseRows(); len(rows) != 2 || !rows[m.ui.expensesList.SelectedRow()].header {
+		t.Errorf("Z: got %d rows, want 2 headers with one selected", len(rows))
+	}
+	press(tea.KeyPressMsg{Code: 'Z', Text: "Z"})
+	if rows := m.expenseRows(); len(rows) != 5 {
+		t.Errorf("Z Z: got %d rows, want 5", len(rows))
+	}
+}
@@ -74,10 +74,32 @@ func (m model) filterExpensesByCategory(categoryName string) []types.Expense {
 	return filtered
 }
 
+// isCompact reports whether the terminal is too small for the full layout, so
+// one box is shown at a time under a tab bar instead
+func (m model) isCompact() bool {
+	if m.ui.width == 0 || m.ui.height == 0 {
+		// Not sized yet
+		return false
+	}
+	return m.ui.width < minWidth || m.ui.height < minHeight
+}
+
+// titleHeight returns the height of the title box, or of the tab bar replacing it in the compact layout
+func (m model) titleHeight() int {
+	if m.isCompact() {
+		return compactTabBarHeight
+	}
+	return titleBoxHeight
+}
+
 // calculateExpensesBoxHeight calculates height for the stats box (middle box)
 func (m model) calculateExpensesBoxHeight() int {
 	// Remaining height after title box
-	remainingHeight := m.ui.height - titleBoxHeight
+	remainingHeight := m.ui.height - m.titleHeight()
+	if m.isCompact() {
+		// The only box shown, above the hint bar
+		return remainingHeight - statusBarHeight
+	}
 
 	// Give stats box half of remaining space (rounded down)
 	// The expenses box will get all remaining space to fill terminal completely
@@ -85,13 +107,68 @@ func (m model) calculateExpensesBoxHeight() int {
 }
 
 func (m model) calculateAddBoxHeight() int {
-	// Remaining height after title box
-	remainingHeight := m.ui.height - titleBoxHeight
+	// Remaining height after title box and hint bar
+	remainingHeight := m.ui.height - m.titleHeight() - statusBarHeight
 
 	// Give add box all remaining space
 	return remainingHeight
 }
 
+// calculateLowerBoxHeight calculates height for the summary and monthly report boxes (bottom row)
+func (m model) calculateLowerBoxHeight() int {
+	if m.isCompact() {
+		return m.ui.height - compactTabBarHeight - statusBarHeight
+	}
+	return m.ui.height - titleBoxHeight - m.calculateExpensesBoxHeight() - statusBarHeight
+}
+
+// compactBox returns the box shown in the compact layout: the selected box, or the
+// one an overlay was opened from
+func (m model) compactBox() selectedBox {
+	for _, box := range []selectedBox{m.ui.selected, m.ui.previousSelected} {
+		switch box {
+		case expensesBox, addBox, summaryBox, monthlyReportBox:
+			return box
+		}
+	}
+	return expensesBox
+}
+
+// inAddForm reports whether the add form is shown: selected, or under the help
+// overlay opened from it
+func (m model) inAddForm() bool {
+	return m.ui.selected == addBox || (m.ui.overlay == overlayHelp && m.ui.previousSelected == addBox)
+}
+
+// formatTitleBoxTitle formats the title box title with the active profile and month,
+// each with the shortcuts that change them
+func (m model) formatTitleBoxTitle() string {
+	if m.profile == "" && m.ui.activeMonth.IsZero() {
+		return "Sana"
+	}
+	shortcutStyle := lipgloss.NewStyle().
+		Foreground(m.styles.Theme.Success).
+		Background(m.styles.Theme.Background)
+	profileStyle := lipgloss.NewStyle().
+		Foreground(m.styles.Theme.Selected).
+		Background(m.styles.Theme.Background).
+		Bold(true)
+	separatorStyle := lipgloss.NewStyle().
+		Foreground(m.styles.Theme.Border).
+		Background(m.styles.Theme.Background)
+
+	title := "Sana"
+	if m.profile != "" {
+		title += separatorStyle.Render(" - ") + shortcutStyle.Render("[p]") + profileStyle.Render(m.profile)
+	}
+	if !m.ui.activeMonth.IsZero() {
+		// "[" and "]" switch to the previous and next month
+		title += separatorStyle.Render(" - ") + shortcutStyle.Render("[") +
+			profileStyle.Render(m.ui.activeMonth.Format("January 2006")) + shortcutStyle.Render("]")
+	}
+	return title
+}
+
 // formatSummaryTitle formats the title for the summary box with bold if selected
 func (m model) formatSummaryTitle(borderColor color.Color, isSelected bool) string {
 	// Shortcut key style - stands out
@@ -113,7 +190,19 @@ func (m model) formatSummaryTitle(borderColor color.Color, isSelected bool) stri
 			Background(m.styles.Theme.Background)
 	}
 
-	return shortcutStyle.Render("[s]") + textStyle.Render("Summary")
+	title := shortcutStyle.Render("[s]") + textStyle.Render("Summary")
+	if !m.ui.filter.isEmpty() {
+		title += m.filterIndicator()
+	}
+	return title
+}
+
+// filterIndicator marks a box title as showing only the filtered expenses
+func (m model) filterIndicator() string {
+	return lipgloss.NewStyle().
+		Foreground(m.styles.Theme.Error).
+		Background(m.styles.Theme.Background).
+		Render(" (filtered)")
 }
 
 // formatExpensesAndAddBoxTitle formats the title for the middle box with bold for selected section
@@ -157,6 +246,16 @@ func (m model) formatExpensesAndAddBoxTitle(borderColor color.Color) string {
 		addText = unselectedStyle.Render("Add Expense")
 	}
 
+	if n := len(m.markedExpenses()); n > 0 {
+		expensesText += lipgloss.NewStyle().
+			Foreground(m.styles.Theme.Selected).
+			Background(m.styles.Theme.Background).
+			Render(fmt.Sprintf(" (%d marked)", n))
+	}
+	if !m.ui.filter.isEmpty() {
+		expensesText += m.filterIndicator() + unselectedStyle.Render(fmt.Sprintf(" %d/%d", len(m.data.expenses), len(m.data.allExpenses)))
+	}
+
 	title := shortcutStyle.Render(shortcutExpensesText) +
 		expensesText +
 		separatorStyle.Render(" - ") +
@@ -65,7 +65,7 @@ func TestCalculateExpensesBoxHeight(t *testing.T) {
 func TestCalculateAddBoxHeight(t *testing.T) {
 	m := model{ui: uiState{height: 30}}
 	got := m.calculateAddBoxHeight()
-	want := 30 - titleBoxHeight
+	want := 30 - titleBoxHeight - statusBarHeight
 	if got != want {
 		t.Errorf("calculateAddBoxHeight() = %d, want %d", got, want)
 	}
@@ -110,3 +110,15 @@ func TestFormatExpensesAndAddBoxTitle(t *testing.T) {
 func minimalModelWithStyles() model {
 	return model{styles: NewStyles(DefaultTheme())}
 }
+
+func TestFormatTitleBoxTitle(t *testing.T) {
+	m := minimalModelWithStyles()
+	if got := m.formatTitleBoxTitle(); got != "Sana" {
+		t.Errorf("formatTitleBoxTitle without profile = %q, want Sana", got)
+	}
+	m.profile = "work"
+	got := m.formatTitleBoxTitle()
+	if !strings.Contains(got, "[p]") || !strings.Contains(got, "work") {
+		t.Errorf("formatTitleBoxTitle should contain [p] and the profile name, got %q", got)
+	}
+}
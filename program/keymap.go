@@ -0,0 +1,262 @@
+package program
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// The keymap lists every key binding with the box or overlay it works in. The help
+// overlay and the hint bar are generated from it, so they only show keys that work
+// where the user is. The key handlers in update.go remain the source of behavior;
+// keep this list in step with them (TestKeymapKeysAreHandled presses every key
+// listed here in its scope and fails on one that does nothing).
+
+// keyScope is where a key binding works.
+type keyScope int
+
+const (
+	scopeGlobal keyScope = iota // every box (not the add form, filter bar or overlays)
+	scopeExpenses
+	scopeFilter
+	scopeAdd
+	scopeSummary
+	scopeMonthlyReport
+	scopeCategoryDetail
+	scopeConfirmDelete
+	scopeExpenseDetail
+	scopeRecategorize
+	scopeCalendar
+	scopeProfiles
+	scopeGoToMonth
+	scopeQuickAdd
+	scopeHelp
+)
+
+// keyScopeNames are the section titles of the help overlay.
+var keyScopeNames = map[keyScope]string{
+	scopeGlobal:         "Global",
+	scopeExpenses:       "Expenses",
+	scopeFilter:         "Filter",
+	scopeAdd:            "Add Expense",
+	scopeSummary:        "Summary",
+	scopeMonthlyReport:  "Monthly Report",
+	scopeCategoryDetail: "Category Detail",
+	scopeConfirmDelete:  "Confirm Delete",
+	scopeExpenseDetail:  "Expense",
+	scopeRecategorize:   "Change Category",
+	scopeCalendar:       "Calendar",
+	scopeProfiles:       "Profiles",
+	scopeGoToMonth:      "Go to Month",
+	scopeQuickAdd:       "Quick Add",
+	scopeHelp:           "Help",
+}
+
+// keyBinding is a key (or group of keys) and what it does.
+type keyBinding struct {
+	scope keyScope
+	keys  string // as shown, e.g. "j/k"
+	desc  string // in the help overlay
+	short string // in the hint bar; empty leaves the binding out of it
+}
+
+// keymap is every key binding, in the order the help overlay lists them.
+var keymap = []keyBinding{
+	{scopeExpenses, "enter", "Expense detail (on a day: collapse)", "detail"},
+	{scopeExpenses, "d", "Delete expense (or the marked ones)", "delete"},
+	{scopeExpenses, "space", "Mark expense", "mark"},
+	{scopeExpenses, "v", "Mark a range", ""},
+	{scopeExpenses, "c", "Change category (of the marked ones)", ""},
+	{scopeExpenses, "D", "Duplicate into the add form, dated today", ""},
+	{scopeExpenses, "/", "Filter expenses", "filter"},
+	{scopeExpenses, "esc", "Cancel range, unmark, clear filter", ""},
+	{scopeExpenses, "o/O", "Sort by next column / reverse", ""},
+	{scopeExpenses, "z/Z", "Group by day / collapse all days", ""},
+
+	{scopeFilter, "enter", "Keep the filter", "keep"},
+	{scopeFilter, "esc", "Clear the filter", "clear"},
+	{scopeFilter, "coffee", "Description contains", ""},
+	{scopeFilter, "@food", "Category", ""},
+	{scopeFilter, ">50 10..50", "Amount", ""},
+	{scopeFilter, "d:15", "Day of the month", ""},
+
+	{scopeAdd, "tab", "Complete suggestion or next field", "complete"},
+	{scopeAdd, "shift+tab", "Previous field", ""},
+	{scopeAdd, "↑/↓", "Previous / next field", "move"},
+	{scopeAdd, "enter", "Save expense", "save"},
+	{scopeAdd, "esc", "Cancel", "cancel"},
+	{scopeAdd, "f1", "Help", "help"},
+
+	{scopeSummary, "space", "Expenses of the category", "detail"},
+	{scopeSummary, "b", "Show / hide the stacked share bar", "bar"},
+
+	{scopeMonthlyReport, "enter", "Make the month active", "select"},
+	{scopeMonthlyReport, "b", "Table / horizontal / vertical bars", "chart"},
+	{scopeMonthlyReport, "h/l", "Previous / next month (vertical bars)", ""},
+
+	{scopeGlobal, "?", "Help (f1)", "help"},
+	{scopeGlobal, "a", "Add expense", "add"},
+	{scopeGlobal, ":", "Quick add (25.5 coffee @food yesterday)", ""},
+	{scopeGlobal, "e/s/m", "Expenses / Summary / Monthly Report box", ""},
+	{scopeGlobal, "tab", "Next box (shift+tab: previous)", ""},
+	{scopeGlobal, "j/k", "Move down / up", ""},
+	{scopeGlobal, "g/G", "Move to top / bottom", ""},
+	{scopeGlobal, "[ ]", "Previous / next month", "month"},
+	{scopeGlobal, ".", "Current month", ""},
+	{scopeGlobal, "M", "Go to month", ""},
+	{scopeGlobal, "C", "Calendar", ""},
+	{scopeGlobal, "p", "Switch profile", ""},
+	{scopeGlobal, "r", "Reload", ""},
+	{scopeGlobal, "q", "Quit", "quit"},
+
+	{scopeCategoryDetail, "j/k", "Move down / up", "move"},
+	{scopeCategoryDetail, "g/G", "Move to top / bottom", ""},
+	{scopeCategoryDetail, "enter", "Expense detail", "detail"},
+	{scopeCategoryDetail, "d", "Delete expense", "delete"},
+	{scopeCategoryDetail, "h/l", "Previous / next category", "category"},
+	{scopeCategoryDetail, "esc", "Close (space)", "close"},
+	{scopeCategoryDetail, "?", "Help (f1)", ""},
+
+	{scopeConfirmDelete, "d/enter", "Delete", "delete"},
+	{scopeConfirmDelete, "esc", "Cancel", "cancel"},
+	{scopeConfirmDelete, "?", "Help (f1)", ""},
+
+	{scopeExpenseDetail, "d", "Delete expense", "delete"},
+	{scopeExpenseDetail, "c", "Duplicate expense, dated today", "duplicate"},
+	{scopeExpenseDetail, "y", "Copy the ID", "copy ID"},
+	{scopeExpenseDetail, "esc", "Close (enter)", "close"},
+	{scopeExpenseDetail, "?", "Help (f1)", ""},
+
+	{scopeRecategorize, "j/k", "Move down / up", "move"},
+	{scopeRecategorize, "enter", "Apply the category", "apply"},
+	{scopeRecategorize, "esc", "Cancel", "cancel"},
+	{scopeRecategorize, "?", "Help (f1)", ""},
+
+	{scopeCalendar, "j/k", "Next / previous day", "day"},
+	{scopeCalendar, "h/l", "Previous / next week", "week"},
+	{scopeCalendar, "g/G", "First / last day", ""},
+	{scopeCalendar, "enter", "Filter the expenses to the day", "show day"},
+	{scopeCalendar, "[ ]", "Previous / next month", "month"},
+	{scopeCalendar, "esc", "Close (C)", "close"},
+	{scopeCalendar, "?", "Help (f1)", ""},
+
+	{scopeProfiles, "j/k", "Move down / up", "move"},
+	{scopeProfiles, "enter", "Switch to the profile", "switch"},
+	{scopeProfiles, "esc", "Cancel (p)", "cancel"},
+	{scopeProfiles, "?", "Help (f1)", ""},
+
+	{scopeGoToMonth, "enter", "Go to the month (YYYY-MM, mar, last, -2)", "go"},
+	{scopeGoToMonth, "esc", "Cancel", "cancel"},
+	{scopeGoToMonth, "f1", "Help", ""},
+
+	{scopeQuickAdd, "enter", "Add the expense", "add"},
+	{scopeQuickAdd, "esc", "Cancel", "cancel"},
+	{scopeQuickAdd, "f1", "Help", ""},
+
+	{scopeHelp, "j/k", "Scroll", "scroll"},
+	{scopeHelp, "esc", "Close (?)", "close"},
+}
+
+// isBoxScope reports whether the global bindings work in scope too.
+func isBoxScope(scope keyScope) bool {
+	return scope == scopeExpenses || scope == scopeSummary || scope == scopeMonthlyReport
+}
+
+// bindings returns the bindings of scope, followed by the global ones for a box.
+func bindings(scope keyScope) []keyBinding {
+	var result []keyBinding
+	for _, b := range keymap {
+		if b.scope == scope {
+			result = append(result, b)
+		}
+	}
+	if isBoxScope(scope) {
+		for _, b := range keymap {
+			if b.scope == scopeGlobal {
+				result = append(result, b)
+			}
+		}
+	}
+	return result
+}
+
+// keyScope returns where the keys pressed now go: the overlay, or else the selected box.
+func (m model) keyScope() keyScope {
+	switch m.ui.overlay {
+	case overlayCategoryDetail:
+		return scopeCategoryDetail
+	case overlayConfirmDelete:
+		return scopeConfirmDelete
+	case overlayHelp:
+		return scopeHelp
+	case overlayProfiles:
+		return scopeProfiles
+	case overlayGoToMonth:
+		return scopeGoToMonth
+	case overlayQuickAdd:
+		return scopeQuickAdd
+	case overlayExpenseDetail:
+		return scopeExpenseDetail
+	case overlayRecategorize:
+		return scopeRecategorize
+	case overlayCalendar:
+		return scopeCalendar
+	}
+	switch m.ui.selected {
+	case addBox:
+		return scopeAdd
+	case summaryBox:
+		return scopeSummary
+	case monthlyReportBox:
+		return scopeMonthlyReport
+	}
+	if m.ui.filtering {
+		return scopeFilter
+	}
+	return scopeExpenses
+}
+
+// helpLines returns the lines of the help overlay for scope: a section per scope
+// with a line per binding, the keys lined up in a column.
+func (m model) helpLines(scope keyScope) []string {
+	list := bindings(scope)
+	keyWidth := 0
+	for _, b := range list {
+		keyWidth = max(keyWidth, ansi.StringWidth(b.keys))
+	}
+	keyStyle := m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true)
+
+	var lines []string
+	section := keyScope(-1)
+	for _, b := range list {
+		if b.scope != section {
+			if section >= 0 {
+				lines = append(lines, "")
+			}
+			section = b.scope
+			lines = append(lines, m.styles.Header.Render(keyScopeNames[section]))
+		}
+		key := b.keys + strings.Repeat(" ", keyWidth-ansi.StringWidth(b.keys)+2)
+		lines = append(lines, keyStyle.Render(key)+m.styles.Muted.Render(b.desc))
+	}
+	return lines
+}
+
+// helpMaxRows returns how many lines of the help overlay fit on the screen.
+func (m model) helpMaxRows() int {
+	// borders, blank line and footer
+	return max(1, m.ui.height-innerHeightPadding-2)
+}
+
+// renderHints renders the key hints of the current scope on one line, for the
+// status bar to cut off when they don't fit.
+func (m model) renderHints() string {
+	keyStyle := m.styles.Base.Foreground(m.styles.Theme.Primary)
+	var hints []string
+	for _, b := range bindings(m.keyScope()) {
+		if b.short != "" {
+			hints = append(hints, keyStyle.Render(b.keys)+m.styles.Muted.Render(" "+b.short))
+		}
+	}
+	return strings.Join(hints, m.styles.Muted.Render(" • "))
+}
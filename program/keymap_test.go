@@ -0,0 +1,282 @@
+package program
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/kyawphyothu/sana/config"
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestBindings(t *testing.T) {
+	has := func(list []keyBinding, scope keyScope, keys string) bool {
+		for _, b := range list {
+			if b.scope == scope && b.keys == keys {
+				return true
+			}
+		}
+		return false
+	}
+	tests := []struct {
+		scope      keyScope
+		keys       string
+		wantGlobal bool
+	}{
+		{scopeExpenses, "d", true},
+		{scopeSummary, "space", true},
+		{scopeMonthlyReport, "b", true},
+		{scopeAdd, "f1", false},
+		{scopeCalendar, "[ ]", false},
+	}
+	for _, tt := range tests {
+		list := bindings(tt.scope)
+		if !has(list, tt.scope, tt.keys) {
+			t.Errorf("bindings(%s): missing %q", keyScopeNames[tt.scope], tt.keys)
+		}
+		if got := has(list, scopeGlobal, "q"); got != tt.wantGlobal {
+			t.Errorf("bindings(%s): global bindings included %v, want %v", keyScopeNames[tt.scope], got, tt.wantGlobal)
+		}
+	}
+	// Keys of one box don't leak into another
+	if has(bindings(scopeSummary), scopeExpenses, "d") || has(bindings(scopeExpenses), scopeSummary, "space") {
+		t.Error("bindings: got another box's bindings")
+	}
+	for _, b := range keymap {
+		if keyScopeNames[b.scope] == "" {
+			t.Errorf("keymap: %q has a scope without a name", b.keys)
+		}
+	}
+}
+
+func TestContextHelp(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.width, m.ui.height = 100, 30
+	press := func(k tea.KeyPressMsg) {
+		updated, _ := m.Update(k)
+		m = updated.(model)
+	}
+
+	press(tea.KeyPressMsg{Code: 's', Text: "s"})
+	press(tea.KeyPressMsg{Code: '?', Text: "?"})
+	help := m.renderHelpOverlay()
+	if m.ui.helpScope != scopeSummary || !strings.Contains(help, "Expenses of the category") || strings.Contains(help, "Mark expense") {
+		t.Errorf("? in the summary box: want the summary and global keys only:\n%s", help)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+
+	// The add form types "?", so f1 opens help there; the form stays underneath
+	press(tea.KeyPressMsg{Code: 'a', Text: "a"})
+	press(tea.KeyPressMsg{Code: tea.KeyF1})
+	if m.ui.overlay != overlayHelp || m.ui.helpScope != scopeAdd || !m.inAddForm() {
+		t.Fatalf("f1 in the add box: overlay %v scope %v, want help on the add form", m.ui.overlay, m.ui.helpScope)
+	}
+	if help := m.renderHelpOverlay(); !strings.Contains(help, "Save expense") || strings.Contains(help, "Quit") {
+		t.Errorf("f1 in the add box: want the add form keys only:\n%s", help)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m.ui.overlay != overlayNone || m.ui.selected != addBox {
+		t.Errorf("esc: overlay %v selected %v, want back in the add box", m.ui.overlay, m.ui.selected)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m.ui.selected != summaryBox {
+		t.Errorf("esc in the add box after help: selected %v, want back in the summary box", m.ui.selected)
+	}
+
+	// Help over an overlay shows its keys and closes back to it
+	press(tea.KeyPressMsg{Code: 'C', Text: "C"})
+	press(tea.KeyPressMsg{Code: '?', Text: "?"})
+	if m.ui.overlay != overlayHelp || m.ui.helpScope != scopeCalendar {
+		t.Fatalf("? in the calendar: overlay %v scope %v, want help on the calendar", m.ui.overlay, m.ui.helpScope)
+	}
+	if help := m.renderHelpOverlay(); !strings.Contains(help, "Next / previous day") || strings.Contains(help, "Quit") {
+		t.Errorf("? in the calendar: want the calendar keys only:\n%s", help)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m.ui.overlay != overlayCalendar || m.ui.selected != calendarOverlay {
+		t.Errorf("esc: overlay %v selected %v, want back in the calendar", m.ui.overlay, m.ui.selected)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m.ui.overlay != overlayNone || m.ui.selected != summaryBox {
+		t.Errorf("esc in the calendar after help: overlay %v selected %v, want the summary box", m.ui.overlay, m.ui.selected)
+	}
+
+	// The quick add prompt types "?"; f1 opens help and keeps what was typed
+	press(tea.KeyPressMsg{Code: ':', Text: ":"})
+	press(tea.KeyPressMsg{Code: '?', Text: "?"})
+	press(tea.KeyPressMsg{Code: tea.KeyF1})
+	if m.ui.overlay != overlayHelp || m.ui.helpScope != scopeQuickAdd {
+		t.Fatalf("f1 in quick add: overlay %v scope %v, want help on quick add", m.ui.overlay, m.ui.helpScope)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m.ui.overlay != overlayQuickAdd || m.ui.quickInput.Value() != "?" {
+		t.Errorf("esc: overlay %v input %q, want back in quick add with %q", m.ui.overlay, m.ui.quickInput.Value(), "?")
+	}
+}
+
+func TestOverlayKeysBeforeBox(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.width, m.ui.height = 100, 30
+	// An overlay over a box that is still selected gets the keys, not the box
+	m.ui.selected = summaryBox
+	m.ui.overlay = overlayGoToMonth
+	updated, _ := m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m = updated.(model)
+	if m.ui.overlay != overlayNone {
+		t.Errorf("esc over the summary box: overlay %v, want closed", m.ui.overlay)
+	}
+}
+
+// keyPresses turns the keys of a binding into the presses they stand for: "j/k" is
+// two keys and "[ ]" two more, named keys are pressed as such and anything else,
+// such as the filter syntax "@food", is typed.
+func keyPresses(keys string) [][]tea.KeyPressMsg {
+	named := map[string]tea.KeyPressMsg{
+		"enter":     {Code: tea.KeyEnter},
+		"esc":       {Code: tea.KeyEscape},
+		"tab":       {Code: tea.KeyTab},
+		"shift+tab": {Code: tea.KeyTab, Mod: tea.ModShift},
+		"space":     {Code: tea.KeySpace, Text: " "},
+		"f1":        {Code: tea.KeyF1},
+		"↑":         {Code: tea.KeyUp},
+		"↓":         {Code: tea.KeyDown},
+	}
+	tokens := []string{keys}
+	if keys != "/" {
+		tokens = strings.FieldsFunc(keys, func(r rune) bool { return r == '/' || r == ' ' })
+	}
+	var presses [][]tea.KeyPressMsg
+	for _, tok := range tokens {
+		if k, ok := named[tok]; ok {
+			presses = append(presses, []tea.KeyPressMsg{k})
+			continue
+		}
+		var typed []tea.KeyPressMsg
+		for _, r := range tok {
+			typed = append(typed, tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+		presses = append(presses, typed)
+	}
+	return presses
+}
+
+// TestKeymapKeysAreHandled presses every key of the keymap in its scope and checks
+// that it does something there (returns a command or changes the screen), so the
+// help overlay and hint bar don't list keys the handlers in update.go have dropped.
+// Global keys are pressed in each box, except the one selecting the box itself.
+func TestKeymapKeysAreHandled(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("SANA_ENV", "")
+	for _, name := range []string{"home", "work"} {
+		if err := config.CreateProfile(name); err != nil {
+			t.Fatalf("CreateProfile: %v", err)
+		}
+	}
+	store := database.NewMemoryStore()
+	month := startOfMonth(time.Now())
+	categories := []types.ExpenseType{types.ExpenseTypeFood, types.ExpenseTypeTransport, types.ExpenseTypeBills}
+	for i := range 12 {
+		if _, err := store.CreateExpense(month.AddDate(0, 0, i).Add(12*time.Hour), float64(i+1), "item", categories[i%3]); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	for i := range 3 {
+		if _, err := store.CreateExpense(month.AddDate(0, -i-1, 1), 10, "earlier", types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+
+	// The profile in the middle of the list is active, so the switcher can move both ways
+	base := InitialModel(store, "home")
+	base.ui.width, base.ui.height = 100, 30
+	for _, msg := range []tea.Msg{loadMonthData(store, month)(), loadMonthlyReportData(store)(), loadDescriptionHistory(store)()} {
+		updated, _ := base.Update(msg)
+		base = updated.(model)
+	}
+	base.ui.expensesList.SetLength(len(base.expenseRows()))
+	press := func(m model, keys ...string) model {
+		for _, k := range keys {
+			for _, typed := range keyPresses(k) {
+				for _, msg := range typed {
+					updated, _ := m.Update(msg)
+					m = updated.(model)
+				}
+			}
+		}
+		return m
+	}
+	screen := func(m model) string {
+		buf := uv.NewScreenBuffer(m.ui.width, m.ui.height)
+		m.View().Content.Draw(buf, buf.Bounds())
+		return buf.Buffer.Render()
+	}
+
+	// Each scope is set up with the cursor away from the ends and something to act
+	// on, afresh for every key since the marks map is shared by copies of a model.
+	// The expenses are grouped by day for Z, with the cursor on an expense.
+	expenses := func() model { return press(base, "z", "j", "j", "space", "j") }
+	setups := map[keyScope]func() model{
+		scopeExpenses: expenses,
+		scopeFilter:   func() model { return press(expenses(), "/") },
+		scopeAdd:      func() model { return press(base, "a") },
+		scopeSummary:  func() model { return press(base, "s", "j") },
+		scopeMonthlyReport: func() model {
+			m := press(base, "m", "j")
+			m.ui.reportView = reportVerticalBars
+			return m
+		},
+		scopeCategoryDetail: func() model { return press(base, "s", "j", "space", "j") },
+		scopeConfirmDelete:  func() model { return press(expenses(), "d") },
+		scopeExpenseDetail:  func() model { return press(expenses(), "enter") },
+		scopeRecategorize:   func() model { return press(expenses(), "c", "j") },
+		scopeCalendar: func() model {
+			m := press(base, "C")
+			m.ui.calendarDay = 10
+			return m
+		},
+		scopeProfiles:  func() model { return press(base, "p") },
+		scopeGoToMonth: func() model { return press(base, "M") },
+		scopeQuickAdd:  func() model { return press(base, ":", "5", "space", "tea") },
+		scopeHelp: func() model {
+			m := base
+			m.ui.height = 20
+			return press(m, "?", "j")
+		},
+	}
+	ownBox := map[keyScope]string{scopeExpenses: "e", scopeSummary: "s", scopeMonthlyReport: "m"}
+
+	for _, b := range keymap {
+		scopes := []keyScope{b.scope}
+		if b.scope == scopeGlobal {
+			scopes = []keyScope{scopeExpenses, scopeSummary, scopeMonthlyReport}
+		}
+		for _, scope := range scopes {
+			setup := setups[scope]
+			if setup == nil {
+				t.Fatalf("keymap: no setup for scope %s", keyScopeNames[scope])
+			}
+			if got := setup().keyScope(); got != scope {
+				t.Fatalf("setup of %s: keys go to %s", keyScopeNames[scope], keyScopeNames[got])
+			}
+			for _, typed := range keyPresses(b.keys) {
+				if len(typed) == 1 && typed[0].Text == ownBox[scope] && typed[0].Text != "" {
+					continue
+				}
+				m := setup()
+				before := screen(m)
+				var cmd tea.Cmd
+				for _, msg := range typed {
+					var updated tea.Model
+					updated, cmd = m.Update(msg)
+					m = updated.(model)
+				}
+				if cmd == nil && screen(m) == before {
+					t.Errorf("%s: %q (%s) does nothing", keyScopeNames[scope], typed[len(typed)-1].String(), b.desc)
+				}
+			}
+		}
+	}
+}
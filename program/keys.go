@@ -1,10 +1,168 @@
 package program
 
-import tea "charm.land/bubbletea/v2"
+import (
+	"slices"
+	"strconv"
+	"time"
 
+	tea "charm.land/bubbletea/v2"
+	"github.com/kyawphyothu/sana/config"
+	"github.com/kyawphyothu/sana/types"
+)
+
+// help shows the key bindings of the current box or overlay. An overlay stays open
+// under it; previousSelected keeps naming the box beneath.
 func (m model) help() (tea.Model, tea.Cmd) {
-	m.ui.previousSelected = m.ui.selected
+	m.ui.helpScope = m.keyScope()
+	m.ui.helpList.reset()
+	m.ui.helpList.SetLength(len(m.helpLines(m.ui.helpScope)))
+	m.ui.helpReturn = helpReturn{selected: m.ui.selected, previousSelected: m.ui.previousSelected, overlay: m.ui.overlay}
+	if m.ui.overlay == overlayNone {
+		m.ui.previousSelected = m.ui.selected
+	}
 	m.ui.selected = helpOverlay
 	m.ui.overlay = overlayHelp
 	return m, nil
 }
+
+// closeHelp closes the help overlay, back to the box or overlay it was opened from.
+func (m *model) closeHelp() {
+	m.ui.selected = m.ui.helpReturn.selected
+	m.ui.previousSelected = m.ui.helpReturn.previousSelected
+	m.ui.overlay = m.ui.helpReturn.overlay
+}
+
+// openProfiles shows the profile switcher with the active profile selected.
+func (m model) openProfiles() (tea.Model, tea.Cmd) {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		return m, m.setStatusErr(err)
+	}
+	m.ui.profiles = profiles
+	m.ui.profileList.reset()
+	m.ui.profileList.SetLength(len(profiles))
+	for i, p := range profiles {
+		if p == m.profile {
+			m.ui.profileList.selectedRow = i
+		}
+	}
+	m.ui.err = nil
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = profileOverlay
+	m.ui.overlay = overlayProfiles
+	return m, nil
+}
+
+// setActiveMonth switches to the month containing t and loads its data.
+// Months without expenses are fine; they just show empty boxes.
+func (m model) setActiveMonth(t time.Time) (tea.Model, tea.Cmd) {
+	m.ui.activeMonth = startOfMonth(t)
+	m.ui.calendarDay = min(m.ui.calendarDay, daysInMonth(m.ui.activeMonth))
+	m.clearMarks()
+	m.ui.expensesList.reset()
+	m.ui.summaryList.reset()
+	return m, loadMonthData(m.store, m.ui.activeMonth)
+}
+
+// openGoToMonth shows the "go to month" prompt, prefilled with the active month.
+func (m model) openGoToMonth() (tea.Model, tea.Cmd) {
+	m.ui.monthInput.SetValue(m.ui.activeMonth.Format("2006-01"))
+	m.ui.monthInput.CursorEnd()
+	m.ui.monthInput.Focus()
+	m.ui.err = nil
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = goToMonthOverlay
+	m.ui.overlay = overlayGoToMonth
+	return m, nil
+}
+
+// openAddForm switches to the add box. When another month is active and the date
+// hasn't been edited, the date starts on that month so future bills can be pre-entered.
+func (m model) openAddForm() (tea.Model, tea.Cmd) {
+	today := time.Now()
+	if m.form.date.Value() == today.Format("2006-01-02") && !sameMonth(m.ui.activeMonth, today) {
+		m.form.date.SetValue(m.ui.activeMonth.Format("2006-01-02"))
+	}
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = addBox
+	return m, nil
+}
+
+// openDuplicate switches to the add box filled in from the selected expense, dated
+// today, with the amount focused: the usual change when the same expense comes again.
+func (m model) openDuplicate() (tea.Model, tea.Cmd) {
+	idx := m.selectedExpenseIndex()
+	if idx < 0 {
+		return m, nil
+	}
+	e := m.data.expenses[idx]
+	m.addFormReset()
+	m.form.typeField.SetValue(e.Type.String())
+	m.form.amount.SetValue(strconv.FormatFloat(e.Amount, 'f', -1, 64))
+	m.form.description.SetValue(e.Description)
+	m.addFormInput().Blur()
+	m.form.focused = addFormAmount
+	m.form.amount.Focus()
+	m.form.amount.CursorEnd()
+	m.ui.err = nil
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = addBox
+	return m, nil
+}
+
+// openExpenseDetail shows every field of the selected expense.
+func (m model) openExpenseDetail() (tea.Model, tea.Cmd) {
+	idx := m.selectedExpenseIndex()
+	if idx < 0 {
+		return m, nil
+	}
+	m.ui.detailExpense = m.data.expenses[idx]
+	m.ui.detailNotice = ""
+	m.ui.err = nil
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = expenseDetailOverlay
+	m.ui.overlay = overlayExpenseDetail
+	return m, nil
+}
+
+// openRecategorize shows the category picker for the marked (or selected) expenses.
+func (m model) openRecategorize() (tea.Model, tea.Cmd) {
+	if len(m.bulkTargets()) == 0 {
+		return m, nil
+	}
+	m.ui.categoryList.reset()
+	m.ui.categoryList.SetLength(len(types.AllExpenseTypes()))
+	m.ui.err = nil
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = recategorizeOverlay
+	m.ui.overlay = overlayRecategorize
+	return m, nil
+}
+
+// openCalendar shows the daily spending heatmap of the active month. The day of the
+// expenses filter is selected, or else today when the current month is active.
+func (m model) openCalendar() (tea.Model, tea.Cmd) {
+	switch {
+	case m.ui.filter.day != 0:
+		m.ui.calendarDay = min(m.ui.filter.day, daysInMonth(m.ui.activeMonth))
+	case sameMonth(m.ui.activeMonth, time.Now()):
+		m.ui.calendarDay = time.Now().Day()
+	default:
+		m.ui.calendarDay = 1
+	}
+	m.ui.err = nil
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = calendarOverlay
+	m.ui.overlay = overlayCalendar
+	return m, nil
+}
+
+// cycleBox selects the next (delta 1) or previous (delta -1) of the expenses, summary
+// and monthly report boxes, the tabs of the compact layout.
+func (m model) cycleBox(delta int) (tea.Model, tea.Cmd) {
+	boxes := []selectedBox{expensesBox, summaryBox, monthlyReportBox}
+	i := max(slices.Index(boxes, m.ui.selected), 0)
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = boxes[(i+delta+len(boxes))%len(boxes)]
+	return m, nil
+}
@@ -0,0 +1,158 @@
+package program
+
+import "github.com/kyawphyothu/sana/types"
+
+// Marks are kept by expense ID so they follow their rows through re-sorting,
+// filtering and reloads. A v-range runs from the anchor expense to the selected row
+// and counts as marked until it is committed with v again or cancelled with esc.
+
+// isMarked reports whether row i of the shown expenses is marked or inside the v-range.
+func (m model) isMarked(i int) bool {
+	if i < 0 || i >= len(m.data.expenses) {
+		return false
+	}
+	if m.ui.marked[m.data.expenses[i].ID] {
+		return true
+	}
+	lo, hi, ok := m.markRange()
+	return ok && i >= lo && i <= hi
+}
+
+// markRange returns the expenses (as indices) of the active v-range. On a day header
+// the range reaches the first expense of that day.
+func (m model) markRange() (lo, hi int, ok bool) {
+	if m.ui.markAnchor == 0 {
+		return 0, 0, false
+	}
+	anchor := -1
+	for i, e := range m.data.expenses {
+		if e.ID == m.ui.markAnchor {
+			anchor = i
+			break
+		}
+	}
+	if anchor < 0 {
+		return 0, 0, false
+	}
+	row, ok := m.selectedExpenseRow()
+	if !ok {
+		return 0, 0, false
+	}
+	lo, hi = anchor, row.index
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return lo, min(hi, len(m.data.expenses)-1), true
+}
+
+// markedExpenses returns the shown expenses the bulk actions apply to, in list order.
+func (m model) markedExpenses() []types.Expense {
+	var marked []types.Expense
+	for i, e := range m.data.expenses {
+		if m.isMarked(i) {
+			marked = append(marked, e)
+		}
+	}
+	return marked
+}
+
+// toggleMark marks or unmarks the selected row and moves to the next one.
+func (m *model) toggleMark() {
+	i := m.selectedExpenseIndex()
+	if i < 0 {
+		return
+	}
+	id := m.data.expenses[i].ID
+	if m.ui.marked == nil {
+		m.ui.marked = map[int64]bool{}
+	}
+	if m.ui.marked[id] {
+		delete(m.ui.marked, id)
+	} else {
+		m.ui.marked[id] = true
+	}
+	m.ui.expensesList.SetLength(len(m.expenseRows()))
+	m.ui.expensesList.moveDown(m.expensesMaxVisibleRows())
+}
+
+// toggleMarkRange starts a v-range at the selected row, or marks every row of the active one.
+func (m *model) toggleMarkRange() {
+	if lo, hi, ok := m.markRange(); ok {
+		if m.ui.marked == nil {
+			m.ui.marked = map[int64]bool{}
+		}
+		for i := lo; i <= hi; i++ {
+			m.ui.marked[m.data.expenses[i].ID] = true
+		}
+		m.ui.markAnchor = 0
+		return
+	}
+	if i := m.selectedExpenseIndex(); i >= 0 {
+		m.ui.markAnchor = m.data.expenses[i].ID
+	}
+}
+
+// hasMarks reports whether any row is marked or a v-range is active.
+func (m model) hasMarks() bool {
+	return len(m.ui.marked) > 0 || m.ui.markAnchor != 0
+}
+
+// clearMarks unmarks every row and cancels the v-range.
+func (m *model) clearMarks() {
+	m.ui.marked = nil
+	m.ui.markAnchor = 0
+}
+
+// pruneMarks drops marks on expenses that are no longer loaded (e.g. deleted elsewhere).
+func (m *model) pruneMarks() {
+	if !m.hasMarks() {
+		return
+	}
+	loaded := make(map[int64]bool, len(m.data.allExpenses))
+	for _, e := range m.data.allExpenses {
+		loaded[e.ID] = true
+	}
+	for id := range m.ui.marked {
+		if !loaded[id] {
+			delete(m.ui.marked, id)
+		}
+	}
+	if !loaded[m.ui.markAnchor] {
+		m.ui.markAnchor = 0
+	}
+}
+
+// bulkTargets returns the expenses a delete or category change applies to:
+// the marked ones, or the selected one when nothing is marked.
+func (m model) bulkTargets() []types.Expense {
+	if marked := m.markedExpenses(); len(marked) > 0 {
+		return marked
+	}
+	if i := m.selectedExpenseIndex(); i >= 0 {
+		return []types.Expense{m.data.expenses[i]}
+	}
+	return nil
+}
+
+// deleteTargets returns the expenses the confirm delete overlay deletes: the one of
+// the detail overlay it was opened from, or else the bulk targets.
+func (m model) deleteTargets() []types.Expense {
+	if m.ui.deleteID == 0 {
+		return m.bulkTargets()
+	}
+	for _, e := range m.data.allExpenses {
+		if e.ID == m.ui.deleteID {
+			return []types.Expense{e}
+		}
+	}
+	return nil
+}
+
+// expenseIDs returns the IDs of expenses.
+func expenseIDs(expenses []types.Expense) []int64 {
+	ids := make([]int64, len(expenses))
+	for i, e := range expenses {
+		ids[i] = e.ID
+	}
+	return ids
+}
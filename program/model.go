@@ -1,14 +1,15 @@
 package program
 
 import (
-	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/kyawphyothu/sana/config"
 	"github.com/kyawphyothu/sana/database"
 	"github.com/kyawphyothu/sana/expense"
 	"github.com/kyawphyothu/sana/types"
@@ -25,6 +26,12 @@ const (
 	categoryDetailOverlay
 	confirmDeleteOverlay
 	helpOverlay
+	profileOverlay
+	goToMonthOverlay
+	expenseDetailOverlay
+	recategorizeOverlay
+	calendarOverlay
+	quickAddOverlay
 )
 
 // overlayKind identifies which overlay is currently visible.
@@ -35,6 +42,12 @@ const (
 	overlayCategoryDetail             // category expense breakdown (from summary box)
 	overlayConfirmDelete              // confirm expense deletion (from expenses box)
 	overlayHelp                       // help overlay (from expenses box)
+	overlayProfiles                   // profile switcher (from any box)
+	overlayGoToMonth                  // "go to month" prompt (from any box)
+	overlayExpenseDetail              // all fields of one expense (from expenses box)
+	overlayRecategorize               // category picker for marked expenses (from expenses box)
+	overlayCalendar                   // daily spending heatmap of the active month (from any box)
+	overlayQuickAdd                   // one-line expense entry (from any box)
 )
 
 // addFormFocus is the index of the focused field in the add-expense form.
@@ -49,11 +62,31 @@ const (
 type addFormFocus int
 
 // expenseData holds loaded expense data from the database.
+// expenses, summary and total are what the boxes show: the loaded month
+// (allExpenses, allSummary, allTotal) narrowed by the expenses filter.
 type expenseData struct {
 	expenses      []types.Expense
 	summary       []types.CategorySummary
 	monthlyReport []types.MonthlyReport
 	total         float64
+	daily         []types.DailyTotal // the whole month, unfiltered
+	// Recent descriptions, most used first: the add form's description suggestions
+	descriptionHistory []types.DescriptionHistory
+	// The store's data version at the last poll, once known: it moves when the
+	// database changes, from outside too
+	dataVersion      int64
+	dataVersionKnown bool
+
+	allExpenses []types.Expense
+	allSummary  []types.CategorySummary
+	allTotal    float64
+}
+
+// helpReturn is where the help overlay goes back to when it closes: the box, or the
+// overlay it was opened over.
+type helpReturn struct {
+	selected, previousSelected selectedBox
+	overlay                    overlayKind
 }
 
 // uiState holds viewport and UI interaction state.
@@ -69,7 +102,65 @@ type uiState struct {
 	summaryList       scrollableList
 	monthlyReportList scrollableList
 
+	// How the monthly report box shows the totals: table or bar chart
+	reportView reportView
+	// Whether the summary box shows the month's split as a stacked bar
+	summaryStackedBar bool
+
 	activeMonth time.Time
+	expenseSort expenseSort
+
+	// Marked expenses (by ID) for bulk actions, the v-range anchor (0: none)
+	// and the category picker for changing their category
+	marked       map[int64]bool
+	markAnchor   int64
+	categoryList scrollableList
+
+	// Expenses filter: the bar's input, whether it is being edited, and the applied filter
+	filterInput textinput.Model
+	filtering   bool
+	filter      expenseFilter
+	filterQuery string
+	filterErr   error
+
+	// Whether the expenses box groups expenses under day headers, and the collapsed days (by dayKey)
+	groupByDay    bool
+	collapsedDays map[string]bool
+
+	// Day selected in the calendar overlay (1-based)
+	calendarDay int
+
+	// Help overlay: the scope it shows the bindings of, its scroll position and
+	// where it goes back to
+	helpScope  keyScope
+	helpList   scrollableList
+	helpReturn helpReturn
+
+	// Profile switcher overlay
+	profiles    []string
+	profileList scrollableList
+
+	// "Go to month" prompt
+	monthInput textinput.Model
+
+	// Quick add prompt: one line such as "25.5 coffee @food yesterday"
+	quickInput textinput.Model
+
+	// Expense detail overlay: the expense shown and the result of the last action
+	detailExpense types.Expense
+	detailNotice  string
+
+	// Category detail overlay: the category shown and the selection in its expenses
+	detailCategory     string
+	categoryDetailList scrollableList
+
+	// Confirm delete overlay: the expense it deletes when opened from a detail
+	// overlay (0: the bulk targets)
+	deleteID int64
+
+	// Status bar message, and the id of the latest one
+	status   statusMessage
+	statusID int
 
 	overlay overlayKind
 	err     error
@@ -77,27 +168,38 @@ type uiState struct {
 
 // addExpenseForm holds the add-expense form inputs and focus state.
 type addExpenseForm struct {
-	description   textinput.Model
-	amount        textinput.Model
-	date          textinput.Model
-	typeField     textinput.Model
-	focused       addFormFocus
-	typeCompleted bool // Track if Type field suggestion was just completed
+	description textinput.Model
+	amount      textinput.Model
+	date        textinput.Model
+	typeField   textinput.Model
+	focused     addFormFocus
+	completed   bool // a suggestion was just completed, so tab moves on
+}
+
+// unlockForm holds the passphrase prompt shown while an encrypted profile is locked.
+type unlockForm struct {
+	profile    string // profile waiting to be unlocked ("" when unlocked)
+	passphrase textinput.Model
+	unlocking  bool // unlock command running
 }
 
 type model struct {
-	db     *sql.DB
-	data   expenseData
-	ui     uiState
-	form   addExpenseForm
-	styles Styles
+	store   database.Store
+	profile string
+	data    expenseData
+	ui      uiState
+	form    addExpenseForm
+	unlock  unlockForm
+	styles  Styles
 }
 
 // monthDataLoadedMsg is sent when month-specific data loading finishes.
 type monthDataLoadedMsg struct {
+	Month    time.Time
 	Expenses []types.Expense
 	Summary  []types.CategorySummary
 	Total    float64
+	Daily    []types.DailyTotal
 	Err      error
 }
 
@@ -107,16 +209,59 @@ type monthlyReportLoadedMsg struct {
 	Err           error
 }
 
+// descriptionHistoryLoadedMsg is sent when the description history loading finishes.
+type descriptionHistoryLoadedMsg struct {
+	History []types.DescriptionHistory
+	Err     error
+}
+
 // expenseCreatedMsg is sent when an expense is created (success or error).
 type expenseCreatedMsg struct {
-	Err error
+	ID     int64
+	Amount float64
+	Type   types.ExpenseType
+	Err    error
+}
+
+// quickAddedMsg is sent when the expense of a quick entry is created (success or error).
+type quickAddedMsg struct {
+	ID     int64
+	Amount float64
+	Type   types.ExpenseType
+	Err    error
 }
 
-// expenseDeletedMsg is sent when an expense is deleted (success or error).
+// expenseDeletedMsg is sent when expenses are deleted (success or error).
 type expenseDeletedMsg struct {
+	IDs []int64
 	Err error
 }
 
+// expensesRecategorizedMsg is sent when the category of expenses has been changed (success or error).
+type expensesRecategorizedMsg struct {
+	Count int
+	Type  types.ExpenseType
+	Err   error
+}
+
+// expenseDuplicatedMsg is sent when an expense has been copied (success or error).
+type expenseDuplicatedMsg struct {
+	ID  int64
+	Err error
+}
+
+// profileSwitchedMsg is sent when another profile's database has been opened (or failed to open).
+type profileSwitchedMsg struct {
+	Profile string
+	Store   database.Store
+	Err     error
+}
+
+// profileLockedMsg is sent when the profile being switched to is encrypted and needs a passphrase.
+type profileLockedMsg struct {
+	Profile string
+}
+
 // formValidationErrMsg is sent when add form validation fails (so the model can set err).
 type formValidationErrMsg struct {
 	Err error
@@ -142,7 +287,19 @@ func setTextInputStyles(ti *textinput.Model, theme Theme) {
 	ti.SetStyles(styles)
 }
 
-func InitialModel(db *sql.DB) model {
+// newUnlockForm returns the passphrase prompt for an encrypted profile.
+func newUnlockForm(profile string, theme Theme) unlockForm {
+	ti := newAddFormInput("", formWidth)
+	ti.Prompt = "Passphrase: "
+	ti.EchoMode = textinput.EchoPassword
+	setTextInputStyles(&ti, theme)
+	ti.Focus()
+	return unlockForm{profile: profile, passphrase: ti}
+}
+
+// InitialModel returns the TUI model for store, the storage of the named profile.
+// A nil store means the profile is encrypted and the TUI starts at the passphrase prompt.
+func InitialModel(store database.Store, profile string) model {
 	theme := DefaultTheme()
 	styles := NewStyles(theme)
 
@@ -150,12 +307,13 @@ func InitialModel(db *sql.DB) model {
 	desc := newAddFormInput("", formWidth)
 	desc.Prompt = "Description: "
 	setTextInputStyles(&desc, theme)
+	desc.ShowSuggestions = true
 
 	amount := newAddFormInput("", formWidth)
 	amount.Prompt = fmt.Sprintf("Amount%s: ", strings.Repeat(".", promptWidth-promptOffsetAmount))
 	setTextInputStyles(&amount, theme)
 
-	date := newAddFormInput("YYYY-MM-DD or YYYY-MM-DD HH:MM:SS or today", formWidth)
+	date := newAddFormInput("YYYY-MM-DD, yesterday, mon, -2d, 14:30", formWidth)
 	date.Prompt = fmt.Sprintf("Date%s: ", strings.Repeat(".", promptWidth-promptOffsetDate))
 	setTextInputStyles(&date, theme)
 	date.SetValue(time.Now().Format("2006-01-02"))
@@ -168,16 +326,39 @@ func InitialModel(db *sql.DB) model {
 
 	typ.Focus()
 
+	filterInput := newAddFormInput("text @category >50 10..50 d:15", formWidth)
+	filterInput.Prompt = "Filter: "
+	setTextInputStyles(&filterInput, theme)
+
+	monthInput := newAddFormInput("YYYY-MM", monthInputWidth)
+	monthInput.Prompt = "Month: "
+	setTextInputStyles(&monthInput, theme)
+
+	quickInput := newAddFormInput("25.5 coffee @food yesterday", quickAddInputWidth)
+	quickInput.Prompt = "> "
+	setTextInputStyles(&quickInput, theme)
+
+	var unlock unlockForm
+	if store == nil {
+		unlock = newUnlockForm(profile, theme)
+	}
+
 	return model{
-		db: db,
+		store:   store,
+		profile: profile,
 		data: expenseData{
 			expenses:      []types.Expense{},
 			summary:       []types.CategorySummary{},
 			monthlyReport: []types.MonthlyReport{},
+			allExpenses:   []types.Expense{},
+			allSummary:    []types.CategorySummary{},
 		},
 		ui: uiState{
 			selected:    expensesBox,
-			activeMonth: time.Now(),
+			activeMonth: startOfMonth(time.Now()),
+			monthInput:  monthInput,
+			quickInput:  quickInput,
+			filterInput: filterInput,
 		},
 		form: addExpenseForm{
 			description: desc,
@@ -186,47 +367,66 @@ func InitialModel(db *sql.DB) model {
 			typeField:   typ,
 			focused:     addFormType,
 		},
+		unlock: unlock,
 		styles: styles,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(loadMonthData(m.db, time.Time{}), loadMonthlyReportData(m.db))
+	if m.isLocked() {
+		return nil
+	}
+	return tea.Batch(loadMonthData(m.store, time.Time{}), loadMonthlyReportData(m.store), loadDescriptionHistory(m.store), loadSettings(), readDataVersion(m.store))
 }
 
-// loadMonthData returns a command that loads expenses, summary, and total for a specific month.
-func loadMonthData(db *sql.DB, date time.Time) tea.Cmd {
+// loadMonthData returns a command that loads expenses, summary, total and daily totals for a specific month.
+// A month without expenses loads as empty (non-nil) lists.
+func loadMonthData(store database.Store, date time.Time) tea.Cmd {
 	if date.IsZero() {
 		date = time.Now()
 	}
+	date = startOfMonth(date)
 	return func() tea.Msg {
-		expenses, err := database.ListExpenses(db, date)
+		expenses, err := store.ListExpenses(date)
 		if err != nil {
-			return monthDataLoadedMsg{Err: err}
+			return monthDataLoadedMsg{Month: date, Err: err}
 		}
 
-		summary, err := database.GetExpensesSummary(db, date)
+		summary, err := store.GetExpensesSummary(date)
 		if err != nil {
-			return monthDataLoadedMsg{Err: err}
+			return monthDataLoadedMsg{Month: date, Err: err}
 		}
 
-		total, err := database.GetTotalExpenses(db, date)
+		total, err := store.GetTotalExpenses(date)
 		if err != nil {
-			return monthDataLoadedMsg{Err: err}
+			return monthDataLoadedMsg{Month: date, Err: err}
 		}
 
+		daily, err := store.GetDailyTotals(date)
+		if err != nil {
+			return monthDataLoadedMsg{Month: date, Err: err}
+		}
+
+		if expenses == nil {
+			expenses = []types.Expense{}
+		}
+		if summary == nil {
+			summary = []types.CategorySummary{}
+		}
 		return monthDataLoadedMsg{
+			Month:    date,
 			Expenses: expenses,
 			Summary:  summary,
 			Total:    total,
+			Daily:    daily,
 		}
 	}
 }
 
 // loadMonthlyReportData returns a command that loads the monthly report (all months).
-func loadMonthlyReportData(db *sql.DB) tea.Cmd {
+func loadMonthlyReportData(store database.Store) tea.Cmd {
 	return func() tea.Msg {
-		monthlyReport, err := database.GetMonthlyReport(db)
+		monthlyReport, err := store.GetMonthlyReport()
 		if err != nil {
 			return monthlyReportLoadedMsg{Err: err}
 		}
@@ -234,6 +434,70 @@ func loadMonthlyReportData(db *sql.DB) tea.Cmd {
 	}
 }
 
+// loadDescriptionHistory returns a command that loads the most used recent descriptions.
+func loadDescriptionHistory(store database.Store) tea.Cmd {
+	return func() tea.Msg {
+		history, err := expense.DescriptionHistory(store, descriptionSuggestionLimit)
+		return descriptionHistoryLoadedMsg{History: history, Err: err}
+	}
+}
+
+// switchProfile returns a command that opens and migrates the named profile's database.
+// Encrypted profiles are reported as locked so the passphrase can be asked for.
+func switchProfile(name string) tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := config.LoadConfig(name)
+		if err != nil {
+			return profileSwitchedMsg{Err: err}
+		}
+		if cfg.Encrypted {
+			return profileLockedMsg{Profile: cfg.Profile}
+		}
+		db, err := database.NewDB(cfg)
+		if err != nil {
+			return profileSwitchedMsg{Err: err}
+		}
+		if err := database.Migrate(db); err != nil {
+			db.Close()
+			return profileSwitchedMsg{Err: err}
+		}
+		return profileSwitchedMsg{Profile: cfg.Profile, Store: database.NewSQLiteStore(db)}
+	}
+}
+
+// unlockProfile returns a command that decrypts, opens and migrates an encrypted profile's database.
+func unlockProfile(name, passphrase string) tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := config.LoadConfig(name)
+		if err != nil {
+			return profileSwitchedMsg{Err: err}
+		}
+		db, err := database.NewEncryptedDB(cfg, passphrase)
+		if err != nil {
+			return profileSwitchedMsg{Err: err}
+		}
+		if err := database.Migrate(db); err != nil {
+			db.Close()
+			return profileSwitchedMsg{Err: err}
+		}
+		return profileSwitchedMsg{Profile: cfg.Profile, Store: database.NewSQLiteStore(db)}
+	}
+}
+
+// isLocked reports whether an encrypted profile is waiting for its passphrase.
+func (m model) isLocked() bool {
+	return m.unlock.profile != ""
+}
+
+// Close closes the store of the active profile. The TUI may switch profiles,
+// so the caller closes the final model rather than the store it started with.
+func (m model) Close() error {
+	if m.store == nil {
+		return nil
+	}
+	return m.store.Close()
+}
+
 func (m *model) moveRowUp() {
 	switch m.ui.selected {
 	case expensesBox:
@@ -248,7 +512,7 @@ func (m *model) moveRowUp() {
 func (m *model) moveRowDown(maxVisibleRows int) {
 	switch m.ui.selected {
 	case expensesBox:
-		m.ui.expensesList.SetLength(len(m.data.expenses))
+		m.ui.expensesList.SetLength(len(m.expenseRows()))
 		m.ui.expensesList.moveDown(maxVisibleRows)
 	case summaryBox:
 		m.ui.summaryList.SetLength(len(m.data.summary))
@@ -267,9 +531,9 @@ func (m *model) resetRowSelection() {
 
 // clampSelections ensures selection indices stay within valid bounds after data changes.
 func (m *model) clampSelections() {
-	if len(m.data.expenses) > 0 {
-		if m.ui.expensesList.SelectedRow() >= len(m.data.expenses) {
-			m.ui.expensesList.selectedRow = len(m.data.expenses) - 1
+	if rows := len(m.expenseRows()); rows > 0 {
+		if m.ui.expensesList.SelectedRow() >= rows {
+			m.ui.expensesList.selectedRow = rows - 1
 		}
 	} else {
 		m.ui.expensesList.reset()
@@ -288,6 +552,23 @@ func (m *model) clampSelections() {
 	} else {
 		m.ui.monthlyReportList.reset()
 	}
+	if m.ui.detailCategory != "" {
+		// The category detail overlay's expenses change with deletes and the filter
+		m.ui.categoryDetailList.SetLength(len(m.categoryExpenses()))
+		m.ui.categoryDetailList.selectRow(m.ui.categoryDetailList.SelectedRow(), m.categoryDetailMaxRows())
+	}
+}
+
+// selectExpenseByID selects the expense with id in the expenses list, if it is loaded.
+// In a collapsed day its header is selected.
+func (m *model) selectExpenseByID(id int64) {
+	for i, e := range m.data.expenses {
+		if e.ID == id {
+			m.ui.expensesList.SetLength(len(m.expenseRows()))
+			m.ui.expensesList.selectRow(m.expenseRowIndex(i), m.expensesMaxVisibleRows())
+			return
+		}
+	}
 }
 
 func (m model) isSelected(box selectedBox) bool {
@@ -308,7 +589,7 @@ func (m *model) moveRowToTop() {
 func (m *model) moveRowToBottom(maxVisibleRows int) {
 	switch m.ui.selected {
 	case expensesBox:
-		m.ui.expensesList.SetLength(len(m.data.expenses))
+		m.ui.expensesList.SetLength(len(m.expenseRows()))
 		m.ui.expensesList.moveToBottom(maxVisibleRows)
 	case summaryBox:
 		m.ui.summaryList.SetLength(len(m.data.summary))
@@ -338,7 +619,7 @@ func (m *model) addFormInput() *textinput.Model {
 // addFormFocusNext moves focus to the next form field (wraps to first).
 func (m *model) addFormFocusNext() {
 	m.addFormInput().Blur()
-	m.form.typeCompleted = false // Reset completion flag when moving focus
+	m.form.completed = false // Reset completion flag when moving focus
 	m.form.focused = (m.form.focused + 1) % addFormNumFields
 	m.addFormInput().Focus()
 }
@@ -346,7 +627,7 @@ func (m *model) addFormFocusNext() {
 // addFormFocusPrev moves focus to the previous form field (wraps to last).
 func (m *model) addFormFocusPrev() {
 	m.addFormInput().Blur()
-	m.form.typeCompleted = false // Reset completion flag when moving focus
+	m.form.completed = false // Reset completion flag when moving focus
 	m.form.focused--
 	if m.form.focused < 0 {
 		m.form.focused = addFormNumFields - 1
@@ -354,29 +635,35 @@ func (m *model) addFormFocusPrev() {
 	m.addFormInput().Focus()
 }
 
-// hasMatchedSuggestions checks if the Type field has matched suggestions
+// hasSuggestions reports whether the focused field completes from suggestions:
+// the Type field (categories) and the Description field (recent descriptions).
+func (m *model) hasSuggestions() bool {
+	return m.form.focused == addFormType || m.form.focused == addFormDescription
+}
+
+// hasMatchedSuggestions checks if the focused field has matched suggestions
 func (m *model) hasMatchedSuggestions() bool {
-	// Only Type field has suggestions enabled
-	if m.form.focused != addFormType {
+	if !m.hasSuggestions() {
 		return false
 	}
 	// Check if there are matched suggestions
-	suggestions := m.form.typeField.MatchedSuggestions()
+	suggestions := m.addFormInput().MatchedSuggestions()
 	return len(suggestions) > 0
 }
 
-// isValueCompleteSuggestion checks if the current Type field value is a complete match for a suggestion
+// isValueCompleteSuggestion checks if the focused field's value is a complete match for a suggestion
 func (m *model) isValueCompleteSuggestion() bool {
-	if m.form.focused != addFormType {
+	if !m.hasSuggestions() {
 		return false
 	}
-	currentValue := strings.ToLower(strings.TrimSpace(m.form.typeField.Value()))
+	in := m.addFormInput()
+	currentValue := strings.ToLower(strings.TrimSpace(in.Value()))
 	if currentValue == "" {
 		return false
 	}
 	// Check against all available suggestions (not just matched ones)
 	// because after accepting, the value is the full suggestion text
-	suggestions := m.form.typeField.AvailableSuggestions()
+	suggestions := in.AvailableSuggestions()
 	for _, suggestion := range suggestions {
 		if strings.ToLower(suggestion) == currentValue {
 			return true
@@ -385,6 +672,21 @@ func (m *model) isValueCompleteSuggestion() bool {
 	return false
 }
 
+// prefillFromHistory fills the empty Type and Amount fields with the usual category
+// and last amount of the description, when it has been used before.
+func (m *model) prefillFromHistory() {
+	h, ok := expense.FindDescription(m.data.descriptionHistory, m.form.description.Value())
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(m.form.typeField.Value()) == "" {
+		m.form.typeField.SetValue(h.Type.String())
+	}
+	if strings.TrimSpace(m.form.amount.Value()) == "" {
+		m.form.amount.SetValue(strconv.FormatFloat(h.Amount, 'f', -1, 64))
+	}
+}
+
 // addFormSubmit gathers form values and runs the shared expense.AddExpense (validation + create).
 // Validation errors are returned as formValidationErrMsg so the TUI can display them.
 func (m *model) addFormSubmit() tea.Cmd {
@@ -392,13 +694,19 @@ func (m *model) addFormSubmit() tea.Cmd {
 	desc := m.form.description.Value()
 	typeStr := m.form.typeField.Value()
 	dateStr := m.form.date.Value()
-	db := m.db
+	store := m.store
 	return func() tea.Msg {
-		_, err := expense.AddExpense(db, amountStr, desc, typeStr, dateStr)
+		id, err := expense.AddExpense(store, amountStr, desc, typeStr, dateStr)
 		if err != nil {
 			return formValidationErrMsg{Err: err}
 		}
-		return expenseCreatedMsg{}
+		// AddExpense has validated both
+		amount, _ := expense.EvalAmount(amountStr)
+		expType, _ := types.ParseExpenseType(typeStr)
+		if expType == "" {
+			expType = types.ExpenseTypeOther
+		}
+		return expenseCreatedMsg{ID: id, Amount: amount, Type: expType}
 	}
 }
 
@@ -408,7 +716,7 @@ func (m *model) addFormReset() {
 	m.form.amount.SetValue("")
 	m.form.date.SetValue(time.Now().Format("2006-01-02"))
 	m.form.typeField.SetValue("")
-	m.form.typeCompleted = false // Reset completion flag
+	m.form.completed = false // Reset completion flag
 	m.addFormInput().Blur()
 	m.form.focused = addFormType
 	m.form.typeField.Focus()
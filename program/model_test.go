@@ -1,9 +1,12 @@
 package program
 
 import (
+	"slices"
 	"testing"
 	"time"
 
+	tea "charm.land/bubbletea/v2"
+	"github.com/kyawphyothu/sana/database"
 	"github.com/kyawphyothu/sana/types"
 )
 
@@ -166,3 +169,461 @@ func TestClampSelectionsEmptyDataResets(t *testing.T) {
 		t.Error("empty monthlyReport should reset list")
 	}
 }
+
+func TestInitialModelWithoutDBIsLocked(t *testing.T) {
+	m := InitialModel(nil, "work")
+	if !m.isLocked() {
+		t.Fatal("InitialModel(nil): want locked model")
+	}
+	if m.unlock.profile != "work" {
+		t.Errorf("unlock profile: got %q, want %q", m.unlock.profile, "work")
+	}
+	if cmd := m.Init(); cmd != nil {
+		t.Error("locked model should not load data on Init")
+	}
+
+	unlocked := InitialModel(nil, "work")
+	unlocked.unlock = unlockForm{}
+	if unlocked.isLocked() {
+		t.Error("model with empty unlock form should not be locked")
+	}
+}
+
+func TestInitLoadsFromStore(t *testing.T) {
+	store := database.NewMemoryStore()
+	if _, err := store.CreateExpense(time.Now(), 12.5, "coffee", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	m := InitialModel(store, "default")
+
+	msg := loadMonthData(m.store, time.Time{})()
+	updated, _ := m.Update(msg)
+	got := updated.(model)
+	if len(got.data.expenses) != 1 || got.data.total != 12.5 {
+		t.Errorf("after loading: got %d expenses, total %.2f; want 1, 12.50", len(got.data.expenses), got.data.total)
+	}
+}
+
+func TestSwitchToLockedProfile(t *testing.T) {
+	store := database.NewMemoryStore()
+	if _, err := store.CreateExpense(time.Now(), 12.5, "coffee", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	m := InitialModel(store, "default")
+	updated, _ := m.Update(loadMonthData(store, time.Time{})())
+	m = updated.(model)
+
+	updated, _ = m.Update(profileLockedMsg{Profile: "work"})
+	m = updated.(model)
+	if !m.isLocked() || m.store != store || m.profile != "default" || len(m.data.expenses) != 1 {
+		t.Fatalf("locked profile: locked %v, profile %q, %d expenses; want the passphrase prompt with default still open",
+			m.isLocked(), m.profile, len(m.data.expenses))
+	}
+
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m = updated.(model)
+	if cmd != nil || m.isLocked() || m.store != store || m.profile != "default" {
+		t.Errorf("esc: locked %v, profile %q, cmd %v; want back on default", m.isLocked(), m.profile, cmd)
+	}
+}
+
+func TestMonthNavigation(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.activeMonth = time.Date(2025, 1, 20, 0, 0, 0, 0, time.Local)
+
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: '[', Text: "["})
+	got := updated.(model)
+	if want := time.Date(2024, 12, 1, 0, 0, 0, 0, time.Local); !got.ui.activeMonth.Equal(want) {
+		t.Errorf("[: activeMonth = %v, want %v", got.ui.activeMonth, want)
+	}
+	if cmd == nil {
+		t.Fatal("[: want a load command")
+	}
+	// Empty month loads as empty lists
+	loaded, ok := cmd().(monthDataLoadedMsg)
+	if !ok || loaded.Err != nil || loaded.Expenses == nil || len(loaded.Expenses) != 0 {
+		t.Errorf("loading an empty month: got %+v", loaded)
+	}
+
+	updated, _ = got.Update(tea.KeyPressMsg{Code: ']', Text: "]"})
+	updated, _ = updated.Update(tea.KeyPressMsg{Code: ']', Text: "]"})
+	got = updated.(model)
+	if want := time.Date(2025, 2, 1, 0, 0, 0, 0, time.Local); !got.ui.activeMonth.Equal(want) {
+		t.Errorf("]]: activeMonth = %v, want %v", got.ui.activeMonth, want)
+	}
+}
+
+func TestMonthDataForOtherMonthIgnored(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.activeMonth = time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	stale := monthDataLoadedMsg{
+		Month:    time.Date(2025, 2, 1, 0, 0, 0, 0, time.Local),
+		Expenses: []types.Expense{{ID: 1}},
+	}
+	updated, _ := m.Update(stale)
+	if got := updated.(model); len(got.data.expenses) != 0 {
+		t.Errorf("data loaded for a previous month should be ignored, got %d expenses", len(got.data.expenses))
+	}
+}
+
+func TestGoToMonth(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	updated, _ := m.Update(tea.KeyPressMsg{Code: 'M', Text: "M"})
+	got := updated.(model)
+	if got.ui.overlay != overlayGoToMonth {
+		t.Fatalf("M: overlay = %v, want overlayGoToMonth", got.ui.overlay)
+	}
+
+	got.ui.monthInput.SetValue("2031-7")
+	updated, _ = got.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	got = updated.(model)
+	if got.ui.err == nil || got.ui.overlay != overlayGoToMonth {
+		t.Errorf("invalid month: want error and prompt kept open, got err=%v overlay=%v", got.ui.err, got.ui.overlay)
+	}
+
+	got.ui.monthInput.SetValue("2031-07")
+	updated, _ = got.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	got = updated.(model)
+	if got.ui.overlay != overlayNone || got.ui.selected != expensesBox {
+		t.Errorf("after go to month: overlay=%v selected=%v, want closed and back on expenses", got.ui.overlay, got.ui.selected)
+	}
+	if got.ui.activeMonth.Format("2006-01") != "2031-07" {
+		t.Errorf("after go to month: activeMonth = %s, want 2031-07", got.ui.activeMonth.Format("2006-01"))
+	}
+}
+
+func TestExpenseDetailOverlay(t *testing.T) {
+	store := database.NewMemoryStore()
+	date := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	for _, desc := range []string{"first", "second"} {
+		if _, err := store.CreateExpense(date, 10, desc, types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = startOfMonth(date)
+	updated, _ := m.Update(loadMonthData(store, date)())
+	m = updated.(model)
+	m.ui.expensesList.SetLength(len(m.data.expenses))
+	m.ui.expensesList.selectRow(1, m.expensesMaxVisibleRows())
+
+	updated, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = updated.(model)
+	if m.ui.overlay != overlayExpenseDetail || m.ui.detailExpense.Description != "first" {
+		t.Fatalf("enter: overlay=%v expense=%q, want detail of \"first\"", m.ui.overlay, m.ui.detailExpense.Description)
+	}
+
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: 'c', Text: "c"})
+	m = updated.(model)
+	dup, ok := cmd().(expenseDuplicatedMsg)
+	if !ok || dup.Err != nil {
+		t.Fatalf("c: got %+v, want expenseDuplicatedMsg", dup)
+	}
+	updated, _ = m.Update(dup)
+	m = updated.(model)
+	updated, _ = m.Update(loadMonthData(store, date)())
+	m = updated.(model)
+	if len(m.data.expenses) != 2 {
+		t.Errorf("after duplicate: got %d expenses in the original month, want 2", len(m.data.expenses))
+	}
+	// The copy is dated today, as D does
+	if copied, err := store.GetExpense(dup.ID); err != nil || copied.Date.Format("2006-01-02") != time.Now().Format("2006-01-02") {
+		t.Errorf("after duplicate: got copy %+v (%v), want it dated today", copied, err)
+	}
+	// The reload keeps the detail's expense selected
+	if sel := m.data.expenses[m.ui.expensesList.SelectedRow()]; sel.ID != m.ui.detailExpense.ID {
+		t.Errorf("after reload: selected expense %d, want %d", sel.ID, m.ui.detailExpense.ID)
+	}
+
+	updated, cmd = m.Update(tea.KeyPressMsg{Code: 'y', Text: "y"})
+	m = updated.(model)
+	if cmd == nil || m.ui.detailNotice == "" {
+		t.Error("y: want a clipboard command and a notice")
+	}
+
+	updated, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m = updated.(model)
+	if m.ui.overlay != overlayNone || m.ui.selected != expensesBox {
+		t.Errorf("esc: overlay=%v selected=%v, want closed on expenses box", m.ui.overlay, m.ui.selected)
+	}
+}
+
+func TestDuplicateIntoAddForm(t *testing.T) {
+	store := database.NewMemoryStore()
+	date := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	if _, err := store.CreateExpense(date, 12.5, "lunch", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = startOfMonth(date)
+	updated, _ := m.Update(loadMonthData(store, date)())
+	m = updated.(model)
+	m.ui.expensesList.SetLength(len(m.data.expenses))
+
+	updated, _ = m.Update(tea.KeyPressMsg{Code: 'D', Text: "D"})
+	m = updated.(model)
+	if m.ui.selected != addBox || m.form.focused != addFormAmount {
+		t.Fatalf("D: selected %v focused %v, want the add box with the amount focused", m.ui.selected, m.form.focused)
+	}
+	today := time.Now().Format("2006-01-02")
+	got := []string{m.form.typeField.Value(), m.form.amount.Value(), m.form.description.Value(), m.form.date.Value()}
+	want := []string{"Food", "12.5", "lunch", today}
+	if !slices.Equal(got, want) {
+		t.Fatalf("D: form %q, want %q", got, want)
+	}
+
+	// Edits go to the amount; enter saves a new expense dated today
+	for _, k := range []tea.KeyPressMsg{{Code: tea.KeyBackspace}, {Code: '8', Text: "8"}} {
+		updated, _ = m.Update(k)
+		m = updated.(model)
+	}
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = updated.(model)
+	if msg, ok := cmd().(expenseCreatedMsg); !ok || msg.Err != nil {
+		t.Fatalf("enter: got %+v, want expenseCreatedMsg", msg)
+	}
+	list, err := store.ListExpenses(time.Now())
+	if err != nil || len(list) == 0 {
+		t.Fatalf("ListExpenses(today): got %d, %v; want the duplicate", len(list), err)
+	}
+	if e := list[0]; e.Amount != 12.8 || e.Description != "lunch" || e.Type != types.ExpenseTypeFood {
+		t.Errorf("duplicate: got %+v, want 12.80 lunch food", e)
+	}
+}
+
+func TestExpenseSortKeepsSelection(t *testing.T) {
+	store := database.NewMemoryStore()
+	date := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	for i, amount := range []float64{20, 5, 50} {
+		if _, err := store.CreateExpense(date.AddDate(0, 0, i), amount, "", types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = startOfMonth(date)
+	updated, _ := m.Update(loadMonthData(store, date)())
+	m = updated.(model)
+	m.selectExpenseByID(2) // amount 5
+
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: 'o', Text: "o"})
+	m = updated.(model)
+	if m.ui.expenseSort.key != sortByAmount || cmd == nil {
+		t.Fatalf("o: sort = %s, want amount desc and a save command", m.ui.expenseSort)
+	}
+	if got := m.data.expenses[m.ui.expensesList.SelectedRow()].ID; got != 2 {
+		t.Errorf("o: selected expense %d, want 2", got)
+	}
+	if got := m.data.expenses[0].Amount; got != 50 {
+		t.Errorf("o: first amount %.2f, want 50", got)
+	}
+
+	updated, _ = m.Update(tea.KeyPressMsg{Code: 'O', Text: "O"})
+	m = updated.(model)
+	if m.ui.expensesList.SelectedRow() != 0 || m.data.expenses[0].ID != 2 {
+		t.Errorf("O: got row %d with expense %d first, want expense 2 first and selected", m.ui.expensesList.SelectedRow(), m.data.expenses[0].ID)
+	}
+
+	// Reloads keep the sort
+	updated, _ = m.Update(loadMonthData(store, date)())
+	m = updated.(model)
+	if m.data.expenses[0].Amount != 5 {
+		t.Errorf("after reload: first amount %.2f, want 5", m.data.expenses[0].Amount)
+	}
+}
+
+func TestExpensesFilterBar(t *testing.T) {
+	store := database.NewMemoryStore()
+	date := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	for _, e := range []struct {
+		amount float64
+		desc   string
+		typ    types.ExpenseType
+	}{{4, "coffee", types.ExpenseTypeFood}, {60, "groceries", types.ExpenseTypeFood}, {90, "electricity", types.ExpenseTypeBills}} {
+		if _, err := store.CreateExpense(date, e.amount, e.desc, e.typ); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = startOfMonth(date)
+	updated, _ := m.Update(loadMonthData(store, date)())
+	m = updated.(model)
+
+	press := func(keys ...tea.KeyPressMsg) {
+		for _, k := range keys {
+			updated, _ := m.Update(k)
+			m = updated.(model)
+		}
+	}
+	typeText := func(s string) {
+		for _, r := range s {
+			press(tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+	}
+
+	press(tea.KeyPressMsg{Code: '/', Text: "/"})
+	if !m.ui.filtering {
+		t.Fatal("/: want the filter bar open")
+	}
+	typeText(">50")
+	if len(m.data.expenses) != 2 || m.data.total != 150 {
+		t.Errorf(">50: got %d expenses totalling %.2f, want 2 totalling 150", len(m.data.expenses), m.data.total)
+	}
+	typeText(" @food")
+	if len(m.data.expenses) != 1 || len(m.data.summary) != 1 || m.data.summary[0].Total != 60 {
+		t.Errorf(">50 @food: got %d expenses, summary %+v; want groceries only", len(m.data.expenses), m.data.summary)
+	}
+
+	// The filter survives closing the bar and reloading the month
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if m.ui.filtering || m.ui.filterQuery != ">50 @food" {
+		t.Errorf("enter: filtering=%v query=%q, want bar closed with the filter kept", m.ui.filtering, m.ui.filterQuery)
+	}
+	updated, _ = m.Update(loadMonthData(store, date)())
+	m = updated.(model)
+	if len(m.data.expenses) != 1 {
+		t.Errorf("after reload: got %d expenses, want 1", len(m.data.expenses))
+	}
+
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if !m.ui.filter.isEmpty() || len(m.data.expenses) != 3 || m.data.total != 154 {
+		t.Errorf("esc: got %d expenses totalling %.2f, want all 3 totalling 154", len(m.data.expenses), m.data.total)
+	}
+}
+
+func TestBulkActions(t *testing.T) {
+	store := database.NewMemoryStore()
+	date := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	for i := range 5 {
+		if _, err := store.CreateExpense(date.AddDate(0, 0, -i), float64(10*(i+1)), "", types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = startOfMonth(date)
+	reload := func() {
+		updated, _ := m.Update(loadMonthData(store, date)())
+		m = updated.(model)
+	}
+	press := func(k tea.KeyPressMsg) tea.Cmd {
+		updated, cmd := m.Update(k)
+		m = updated.(model)
+		return cmd
+	}
+	reload()
+
+	// space marks row 0 and moves down; v on row 2, j, v marks rows 2-3
+	press(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	press(tea.KeyPressMsg{Code: 'v', Text: "v"})
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	if got := len(m.markedExpenses()); got != 3 {
+		t.Errorf("during v-range: got %d marked, want 3", got)
+	}
+	press(tea.KeyPressMsg{Code: 'v', Text: "v"})
+	if got := expenseIDs(m.markedExpenses()); len(got) != 3 || got[0] != 1 || got[1] != 3 || got[2] != 4 {
+		t.Fatalf("after v-range: got marked %v, want [1 3 4]", got)
+	}
+
+	// Change their category in one go
+	press(tea.KeyPressMsg{Code: 'c', Text: "c"})
+	if m.ui.overlay != overlayRecategorize {
+		t.Fatalf("c: overlay = %v, want category picker", m.ui.overlay)
+	}
+	for m.ui.categoryList.SelectedRow() < len(types.AllExpenseTypes())-1 {
+		press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	}
+	msg := press(tea.KeyPressMsg{Code: tea.KeyEnter})()
+	updated, _ := m.Update(msg)
+	m = updated.(model)
+	reload()
+	if m.hasMarks() || m.ui.overlay != overlayNone {
+		t.Errorf("after recategorize: marks %v, overlay %v; want cleared and closed", m.ui.marked, m.ui.overlay)
+	}
+	if len(m.data.summary) != 2 || m.data.summary[0].Category != "Other" || m.data.summary[0].Count != 3 {
+		t.Errorf("after recategorize: summary %+v, want Other (3) first", m.data.summary)
+	}
+
+	// Delete two marked expenses with one confirmation
+	m.ui.expensesList.reset()
+	press(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	press(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	press(tea.KeyPressMsg{Code: 'd', Text: "d"})
+	if m.ui.overlay != overlayConfirmDelete {
+		t.Fatalf("d: overlay = %v, want confirm delete", m.ui.overlay)
+	}
+	msg = press(tea.KeyPressMsg{Code: 'd', Text: "d"})()
+	updated, _ = m.Update(msg)
+	m = updated.(model)
+	reload()
+	if len(m.data.expenses) != 3 || m.hasMarks() {
+		t.Errorf("after bulk delete: got %d expenses, marks %v; want 3 and none", len(m.data.expenses), m.ui.marked)
+	}
+
+	// esc unmarks before it clears the filter
+	press(tea.KeyPressMsg{Code: tea.KeySpace, Text: " "})
+	m.setFilterQuery(">0")
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if m.hasMarks() || m.ui.filter.isEmpty() {
+		t.Errorf("esc: marks %v, filter empty %v; want marks cleared and filter kept", m.ui.marked, m.ui.filter.isEmpty())
+	}
+}
+
+func TestDescriptionSuggestions(t *testing.T) {
+	store := database.NewMemoryStore()
+	now := time.Now()
+	for i, e := range []struct {
+		desc   string
+		amount float64
+		typ    types.ExpenseType
+	}{
+		{"coffee", 4, types.ExpenseTypeFood},
+		{"coffee", 4.5, types.ExpenseTypeFood},
+		{"cinema", 12, types.ExpenseTypeEntertainment},
+	} {
+		if _, err := store.CreateExpense(now.Add(time.Duration(i-3)*time.Minute), e.amount, e.desc, e.typ); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	updated, _ := m.Update(loadDescriptionHistory(store)())
+	m = updated.(model)
+	press := func(keys ...tea.KeyPressMsg) {
+		for _, k := range keys {
+			updated, _ := m.Update(k)
+			m = updated.(model)
+		}
+	}
+	tab := tea.KeyPressMsg{Code: tea.KeyTab}
+	down := tea.KeyPressMsg{Code: tea.KeyDown}
+
+	// "c" suggests the most used description; tab picks it and fills in the rest
+	press(tea.KeyPressMsg{Code: 'a', Text: "a"}, down, down, tea.KeyPressMsg{Code: 'c', Text: "c"})
+	if got := m.form.description.CurrentSuggestion(); got != "coffee" {
+		t.Fatalf("c: suggestion %q, want coffee", got)
+	}
+	press(tab)
+	if got := []string{m.form.description.Value(), m.form.typeField.Value(), m.form.amount.Value()}; !slices.Equal(got, []string{"coffee", "Food", "4.5"}) {
+		t.Fatalf("tab: form %q, want coffee Food 4.5", got)
+	}
+	press(tab)
+	if m.form.focused != addFormDate {
+		t.Errorf("tab after picking: focused %v, want the date", m.form.focused)
+	}
+
+	// Fields already filled in are kept
+	m.addFormReset()
+	press(tea.KeyPressMsg{Code: 'b', Text: "b"}, down, down)
+	for _, r := range "cin" {
+		press(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+	press(tab)
+	if got := []string{m.form.description.Value(), m.form.typeField.Value(), m.form.amount.Value()}; !slices.Equal(got, []string{"cinema", "b", "12"}) {
+		t.Errorf("tab with a type: form %q, want cinema b 12", got)
+	}
+}
@@ -0,0 +1,341 @@
+package program
+
+import (
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Mouse events are mapped back onto the layout View draws: a click selects the box
+// (and row) under the pointer, the wheel scrolls the list under it, and the key hints
+// in an overlay's footer act as buttons. Actions go through the key handlers, so a
+// click does exactly what the key it stands for does.
+
+// rect is an area of the screen, in cells.
+type rect struct {
+	x, y, w, h int
+}
+
+// contains reports whether the cell at x, y is inside r.
+func (r rect) contains(x, y int) bool {
+	return x >= r.x && x < r.x+r.w && y >= r.y && y < r.y+r.h
+}
+
+// overlayButton is a key hint in an overlay footer, e.g. "Esc: cancel"; clicking its
+// label presses key.
+type overlayButton struct {
+	label string
+	key   string
+}
+
+// compactTabs are the tabs of the compact layout's tab bar, in order.
+var compactTabs = []struct {
+	box   selectedBox
+	label string
+}{
+	{expensesBox, "Expenses"},
+	{summaryBox, "Summary"},
+	{monthlyReportBox, "Report"},
+}
+
+// overlayButtons returns the footer buttons of the active overlay.
+func (m model) overlayButtons() []overlayButton {
+	switch m.ui.overlay {
+	case overlayCategoryDetail:
+		return []overlayButton{{"Enter: detail", "enter"}, {"d: delete", "d"}, {"h/l: category", "l"}, {"Esc: close", "esc"}}
+	case overlayConfirmDelete:
+		label := "d/Enter: delete"
+		if len(m.deleteTargets()) > 1 {
+			label = "d/Enter: delete all"
+		}
+		return []overlayButton{{label, "enter"}, {"Esc: cancel", "esc"}}
+	case overlayRecategorize:
+		return []overlayButton{{"Enter: apply", "enter"}, {"Esc: cancel", "esc"}}
+	case overlayCalendar:
+		return []overlayButton{{"Enter: show day", "enter"}, {"[: prev", "["}, {"]: next", "]"}, {"Esc: close", "esc"}}
+	case overlayExpenseDetail:
+		return []overlayButton{{"d: delete", "d"}, {"c: duplicate", "c"}, {"y: copy ID", "y"}, {"Esc: close", "esc"}}
+	case overlayGoToMonth:
+		return []overlayButton{{"Enter: go", "enter"}, {"Esc: cancel", "esc"}}
+	case overlayQuickAdd:
+		return []overlayButton{{"Enter: add", "enter"}, {"Esc: cancel", "esc"}}
+	case overlayProfiles:
+		return []overlayButton{{"Enter: switch", "enter"}, {"Esc: cancel", "esc"}}
+	case overlayHelp:
+		if len(m.helpLines(m.ui.helpScope)) > m.helpMaxRows() {
+			return []overlayButton{{"j/k: scroll", "j"}, {"Esc: close", "esc"}}
+		}
+		return []overlayButton{{"Esc: close", "esc"}}
+	}
+	return nil
+}
+
+// joinButtons returns the footer text of buttons, e.g. "Enter: go • Esc: cancel".
+func joinButtons(buttons []overlayButton) string {
+	labels := make([]string, len(buttons))
+	for i, b := range buttons {
+		labels[i] = b.label
+	}
+	return strings.Join(labels, " • ")
+}
+
+// renderOverlayButtons renders the footer of the active overlay.
+func (m model) renderOverlayButtons() string {
+	return m.styles.Muted.Render(joinButtons(m.overlayButtons()))
+}
+
+// keyPress returns the key press a mouse action stands for, e.g. "enter" or "d".
+func keyPress(key string) tea.KeyPressMsg {
+	switch key {
+	case "enter":
+		return tea.KeyPressMsg{Code: tea.KeyEnter}
+	case "esc":
+		return tea.KeyPressMsg{Code: tea.KeyEscape}
+	case "space":
+		return tea.KeyPressMsg{Code: tea.KeySpace, Text: " "}
+	case "up":
+		return tea.KeyPressMsg{Code: tea.KeyUp}
+	case "down":
+		return tea.KeyPressMsg{Code: tea.KeyDown}
+	}
+	return tea.KeyPressMsg{Code: []rune(key)[0], Text: key}
+}
+
+// overlayRect returns where View draws the rendered overlay: centered on the screen.
+func (m model) overlayRect(overlay string) rect {
+	lines := strings.Split(overlay, "\n")
+	width := 0
+	for _, line := range lines {
+		width = max(width, ansi.StringWidth(line))
+	}
+	return rect{
+		x: max((m.ui.width-width)/2, 0),
+		y: max((m.ui.height-len(lines))/2, 0),
+		w: width,
+		h: len(lines),
+	}
+}
+
+// boxRect returns where box is drawn; ok is false when it isn't shown.
+func (m model) boxRect(box selectedBox) (rect, bool) {
+	if m.isCompact() {
+		if box != m.compactBox() {
+			return rect{}, false
+		}
+		return rect{0, compactTabBarHeight, m.ui.width, m.calculateLowerBoxHeight()}, true
+	}
+	expensesHeight := m.calculateExpensesBoxHeight()
+	lowerY := titleBoxHeight + expensesHeight
+	switch box {
+	case expensesBox:
+		return rect{0, titleBoxHeight, m.ui.width, expensesHeight}, true
+	case summaryBox:
+		return rect{0, lowerY, m.ui.width / 2, m.calculateLowerBoxHeight()}, true
+	case monthlyReportBox:
+		return rect{m.ui.width / 2, lowerY, m.ui.width - m.ui.width/2, m.calculateLowerBoxHeight()}, true
+	}
+	return rect{}, false
+}
+
+// boxAt returns the box drawn at x, y.
+func (m model) boxAt(x, y int) (selectedBox, bool) {
+	for _, box := range []selectedBox{expensesBox, summaryBox, monthlyReportBox} {
+		if r, ok := m.boxRect(box); ok && r.contains(x, y) {
+			return box, true
+		}
+	}
+	return 0, false
+}
+
+// boxList returns the list of box and its number of rows.
+func (m *model) boxList(box selectedBox) (*scrollableList, int) {
+	switch box {
+	case expensesBox:
+		return &m.ui.expensesList, len(m.expenseRows())
+	case summaryBox:
+		return &m.ui.summaryList, len(m.data.summary)
+	case monthlyReportBox:
+		return &m.ui.monthlyReportList, len(m.data.monthlyReport)
+	}
+	return nil, 0
+}
+
+// boxRows returns the screen line of box's first row and how many rows are drawn,
+// the same way its renderer lays them out.
+func (m model) boxRows(box selectedBox) (first, visible int) {
+	r, _ := m.boxRect(box)
+	first = r.y + 3 // top border + header + separator
+	switch box {
+	case expensesBox:
+		visible = r.h - expensesBoxHeaderRows
+		if m.showFilterBar() {
+			visible--
+		}
+	case summaryBox:
+		visible = r.h - summaryBoxHeaderRows
+		if m.ui.summaryStackedBar {
+			first++
+			visible--
+		}
+	case monthlyReportBox:
+		visible = r.h - monthlyReportBoxHeaderRows
+	}
+	return first, max(visible, 1)
+}
+
+// rowAt returns the row of box drawn at x, y.
+func (m model) rowAt(box selectedBox, x, y int) (int, bool) {
+	if box == monthlyReportBox && m.ui.reportView == reportVerticalBars {
+		return m.monthColumnAt(x)
+	}
+	list, n := m.boxList(box)
+	first, visible := m.boxRows(box)
+	if y < first || y >= first+visible {
+		return 0, false
+	}
+	row := list.ScrollOffset() + y - first
+	return row, row < n
+}
+
+// monthColumnAt returns the monthly report row of the vertical bar chart column at x.
+func (m model) monthColumnAt(x int) (int, bool) {
+	r, _ := m.boxRect(monthlyReportBox)
+	start, end := m.monthlyReportColumnRange(r.w - tableBorderPadding)
+	col := x - r.x - 2 // border + padding
+	if col < 0 || col%monthlyReportColumnWidth == monthlyReportColumnWidth-1 {
+		return 0, false
+	}
+	i := start + col/monthlyReportColumnWidth
+	if i >= end {
+		return 0, false
+	}
+	return len(m.data.monthlyReport) - 1 - i, true
+}
+
+// handleMouseClick selects the box and row under the pointer. Clicking the selected
+// row again opens it, as enter (space in the summary box) does; a month in the
+// monthly report is activated on the first click. In an overlay the footer buttons
+// are pressed, and a click outside it closes it.
+func (m model) handleMouseClick(msg tea.MouseClickMsg) (tea.Model, tea.Cmd) {
+	if msg.Button != tea.MouseLeft || m.isLocked() || m.ui.selected == addBox {
+		return m, nil
+	}
+	if m.ui.overlay != overlayNone {
+		return m.clickOverlay(msg.X, msg.Y)
+	}
+	if m.ui.filtering {
+		m.keepFilter()
+	}
+
+	if m.isCompact() && msg.Y < compactTabBarHeight {
+		x := 0
+		for _, tab := range compactTabs {
+			width := len(tab.label) + 2
+			if msg.X >= x && msg.X < x+width {
+				m.selectBox(tab.box)
+				return m, nil
+			}
+			x += width
+		}
+		return m, nil
+	}
+
+	box, ok := m.boxAt(msg.X, msg.Y)
+	if !ok {
+		return m, nil
+	}
+	wasSelected := m.ui.selected == box
+	m.selectBox(box)
+	row, ok := m.rowAt(box, msg.X, msg.Y)
+	if !ok {
+		return m, nil
+	}
+	list, n := m.boxList(box)
+	list.SetLength(n)
+	wasSelected = wasSelected && list.SelectedRow() == row
+	_, visible := m.boxRows(box)
+	list.selectRow(row, visible)
+
+	switch {
+	case box == monthlyReportBox:
+		return m.handleKeyPress(keyPress("enter"))
+	case !wasSelected:
+		return m, nil
+	case box == summaryBox:
+		return m.handleKeyPress(keyPress("space"))
+	}
+	return m.handleKeyPress(keyPress("enter"))
+}
+
+// clickOverlay presses the footer button at x, y of the active overlay, or closes
+// the overlay when the click is outside it.
+func (m model) clickOverlay(x, y int) (tea.Model, tea.Cmd) {
+	overlay := m.renderOverlay()
+	r := m.overlayRect(overlay)
+	if !r.contains(x, y) {
+		return m.handleKeyPress(keyPress("esc"))
+	}
+	line := ansi.Strip(strings.Split(overlay, "\n")[y-r.y])
+	footer := joinButtons(m.overlayButtons())
+	start := strings.Index(line, footer)
+	if footer == "" || start < 0 {
+		return m, nil
+	}
+	col := x - r.x - ansi.StringWidth(line[:start])
+	for _, b := range m.overlayButtons() {
+		width := ansi.StringWidth(b.label)
+		if col >= 0 && col < width {
+			return m.handleKeyPress(keyPress(b.key))
+		}
+		col -= width + ansi.StringWidth(" • ")
+	}
+	return m, nil
+}
+
+// handleMouseWheel scrolls the list of the box under the pointer, leaving the
+// selected box as it is. In an overlay the wheel moves its selection like up/down.
+func (m model) handleMouseWheel(msg tea.MouseWheelMsg) (tea.Model, tea.Cmd) {
+	if m.isLocked() || m.ui.selected == addBox {
+		return m, nil
+	}
+	delta := 0
+	switch msg.Button {
+	case tea.MouseWheelUp:
+		delta = -1
+	case tea.MouseWheelDown:
+		delta = 1
+	default:
+		return m, nil
+	}
+	if m.ui.overlay != overlayNone {
+		if delta < 0 {
+			return m.handleKeyPress(keyPress("up"))
+		}
+		return m.handleKeyPress(keyPress("down"))
+	}
+
+	box, ok := m.boxAt(msg.X, msg.Y)
+	if !ok {
+		return m, nil
+	}
+	list, n := m.boxList(box)
+	list.SetLength(n)
+	if box == monthlyReportBox && m.ui.reportView == reportVerticalBars {
+		// Columns have no scroll offset; the wheel moves between months instead
+		list.selectRow(list.SelectedRow()+delta, 1)
+		return m, nil
+	}
+	_, visible := m.boxRows(box)
+	list.scroll(delta, visible)
+	return m, nil
+}
+
+// selectBox makes box the selected one.
+func (m *model) selectBox(box selectedBox) {
+	if m.ui.selected == box {
+		return
+	}
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = box
+}
@@ -0,0 +1,125 @@
+package program
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestRectContains(t *testing.T) {
+	r := rect{x: 2, y: 1, w: 3, h: 2}
+	tests := []struct {
+		x, y int
+		want bool
+	}{
+		{2, 1, true},
+		{4, 2, true},
+		{5, 1, false},
+		{2, 3, false},
+		{1, 1, false},
+	}
+	for _, tt := range tests {
+		if got := r.contains(tt.x, tt.y); got != tt.want {
+			t.Errorf("contains(%d, %d): got %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestMouse(t *testing.T) {
+	store := database.NewMemoryStore()
+	date := time.Date(2025, 3, 15, 12, 0, 0, 0, time.Local)
+	for i := range 20 {
+		if _, err := store.CreateExpense(date.Add(-time.Duration(i)*time.Hour), float64(i+1), "", types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	if _, err := store.CreateExpense(date.AddDate(0, -1, 0), 5, "", types.ExpenseTypeBills); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = startOfMonth(date)
+	for _, msg := range []tea.Msg{loadMonthData(store, date)(), loadMonthlyReportData(store)()} {
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+	send := func(msg tea.Msg) {
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+	click := func(x, y int) { send(tea.MouseClickMsg{X: x, Y: y, Button: tea.MouseLeft}) }
+
+	// Clicking a row selects it; clicking it again opens it
+	expenses, _ := m.boxRect(expensesBox)
+	first, _ := m.boxRows(expensesBox)
+	click(10, first+2)
+	if m.ui.selected != expensesBox || m.ui.expensesList.SelectedRow() != 2 || m.ui.overlay != overlayNone {
+		t.Fatalf("click on row 2: selected %v row %d, want expenses box row 2", m.ui.selected, m.ui.expensesList.SelectedRow())
+	}
+	click(10, first+2)
+	if m.ui.overlay != overlayExpenseDetail {
+		t.Fatalf("second click: overlay %v, want expense detail", m.ui.overlay)
+	}
+
+	// The footer buttons of an overlay press their key; a click outside closes it
+	overlay := m.renderOverlay()
+	r := m.overlayRect(overlay)
+	for y, line := range strings.Split(overlay, "\n") {
+		if i := strings.Index(ansi.Strip(line), "Esc: close"); i >= 0 {
+			click(r.x+ansi.StringWidth(ansi.Strip(line)[:i]), r.y+y)
+			break
+		}
+	}
+	if m.ui.overlay != overlayNone {
+		t.Fatalf("click on Esc: close: overlay %v, want closed", m.ui.overlay)
+	}
+	send(keyPress("?"))
+	click(0, 0)
+	if m.ui.overlay != overlayNone {
+		t.Errorf("click outside the help overlay: overlay %v, want closed", m.ui.overlay)
+	}
+
+	// The wheel scrolls the box under the pointer without selecting it
+	click(expenses.w-1, expenses.y)
+	send(tea.MouseWheelMsg{X: 10, Y: first, Button: tea.MouseWheelDown})
+	send(tea.MouseWheelMsg{X: 10, Y: first, Button: tea.MouseWheelDown})
+	if m.ui.expensesList.ScrollOffset() != 2 || m.ui.expensesList.SelectedRow() != 2 {
+		t.Errorf("wheel down twice: offset %d row %d, want 2, 2", m.ui.expensesList.ScrollOffset(), m.ui.expensesList.SelectedRow())
+	}
+
+	// Clicking a month in the monthly report activates it
+	report, _ := m.boxRect(monthlyReportBox)
+	reportFirst, _ := m.boxRows(monthlyReportBox)
+	click(report.x+3, reportFirst+1)
+	if m.ui.selected != monthlyReportBox || !sameMonth(m.ui.activeMonth, date.AddDate(0, -1, 0)) {
+		t.Errorf("click on February: selected %v, active %s; want the report box on 2025-02", m.ui.selected, m.ui.activeMonth.Format("2006-01"))
+	}
+
+	// Clicking a box's border selects it
+	summary, _ := m.boxRect(summaryBox)
+	click(summary.x, summary.y)
+	if m.ui.selected != summaryBox {
+		t.Errorf("click on the summary box: selected %v, want summary", m.ui.selected)
+	}
+}
+
+func TestMouseCompactTabs(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.width, m.ui.height = compactMinWidth, compactMinHeight
+	// " Expenses " then " Summary " then " Report "
+	for _, tt := range []struct {
+		x    int
+		want selectedBox
+	}{{12, summaryBox}, {22, monthlyReportBox}, {3, expensesBox}} {
+		updated, _ := m.Update(tea.MouseClickMsg{X: tt.x, Y: 0, Button: tea.MouseLeft})
+		m = updated.(model)
+		if m.ui.selected != tt.want {
+			t.Errorf("click on tab bar at %d: selected %v, want %v", tt.x, m.ui.selected, tt.want)
+		}
+	}
+}
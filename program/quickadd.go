@@ -0,0 +1,55 @@
+package program
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"github.com/kyawphyothu/sana/expense"
+	"github.com/kyawphyothu/sana/types"
+)
+
+// The quick add prompt takes an expense on one line, in the grammar of
+// expense.ParseQuickEntry, and shows how it is understood while it is typed.
+
+// openQuickAdd shows the quick add prompt, empty.
+func (m model) openQuickAdd() (tea.Model, tea.Cmd) {
+	m.ui.quickInput.SetValue("")
+	m.ui.quickInput.Focus()
+	m.ui.err = nil
+	m.ui.previousSelected = m.ui.selected
+	m.ui.selected = quickAddOverlay
+	m.ui.overlay = overlayQuickAdd
+	return m, nil
+}
+
+// closeQuickAdd closes the quick add prompt, back to the box it was opened from.
+func (m *model) closeQuickAdd() {
+	m.ui.quickInput.Blur()
+	m.ui.selected = m.ui.previousSelected
+	m.ui.overlay = overlayNone
+	m.ui.err = nil
+}
+
+// quickEntryType returns the category a quick entry gets and whether it comes from
+// the description's history rather than an @category.
+func (m model) quickEntryType(q expense.QuickEntry) (t types.ExpenseType, usual bool) {
+	if q.Type != "" {
+		return q.Type, false
+	}
+	if h, ok := expense.FindDescription(m.data.descriptionHistory, q.Description); ok {
+		return h.Type, true
+	}
+	return types.ExpenseTypeOther, false
+}
+
+// submitQuickAdd returns a command that creates the expense of the prompt's entry.
+func (m model) submitQuickAdd() tea.Cmd {
+	line := m.ui.quickInput.Value()
+	store := m.store
+	return func() tea.Msg {
+		q, err := expense.ParseQuickEntry(line)
+		if err != nil {
+			return quickAddedMsg{Err: err}
+		}
+		id, t, err := expense.AddQuickEntry(store, q)
+		return quickAddedMsg{ID: id, Amount: q.Amount, Type: t, Err: err}
+	}
+}
@@ -0,0 +1,99 @@
+package program
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestQuickAdd(t *testing.T) {
+	store := database.NewMemoryStore()
+	if _, err := store.CreateExpense(time.Now().Add(-time.Hour), 4, "coffee", types.ExpenseTypeFood); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	updated, _ := m.Update(loadDescriptionHistory(store)())
+	m = updated.(model)
+	typeText := func(s string) {
+		for _, r := range s {
+			updated, _ := m.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+			m = updated.(model)
+		}
+	}
+
+	typeText(":")
+	if m.ui.overlay != overlayQuickAdd || m.ui.selected != quickAddOverlay {
+		t.Fatalf(": overlay=%v selected=%v, want the quick add prompt", m.ui.overlay, m.ui.selected)
+	}
+
+	// Enter does nothing while the entry doesn't parse; the preview says why
+	typeText("coffee")
+	if preview := ansi.Strip(m.renderQuickAddPreview()); !strings.Contains(preview, "missing amount") {
+		t.Errorf("preview of %q: got %q, want the missing amount", m.ui.quickInput.Value(), preview)
+	}
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter}); cmd != nil {
+		t.Errorf("enter on %q: got a command, want none", m.ui.quickInput.Value())
+	}
+
+	// An amount alone is not an expense either
+	updated, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m = updated.(model)
+	typeText(":10")
+	if preview := ansi.Strip(m.renderQuickAddPreview()); !strings.Contains(preview, "missing description") {
+		t.Errorf("preview of %q: got %q, want the missing description", m.ui.quickInput.Value(), preview)
+	}
+	if _, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter}); cmd != nil {
+		t.Errorf("enter on %q: got a command, want none", m.ui.quickInput.Value())
+	}
+
+	updated, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m = updated.(model)
+
+	typeText(":coffee 45/3 yesterday")
+	preview := ansi.Strip(m.renderQuickAddPreview())
+	for _, want := range []string{"15.00 (45/3)", "Food (usual)", "coffee", time.Now().AddDate(0, 0, -1).Format("2006-01-02")} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("preview of %q: got %q, want %q in it", m.ui.quickInput.Value(), preview, want)
+		}
+	}
+	if got := strings.Count(preview, "\n") + 1; got != quickAddPreviewLines {
+		t.Errorf("preview: got %d lines, want %d", got, quickAddPreviewLines)
+	}
+
+	updated, cmd := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = updated.(model)
+	msg, ok := cmd().(quickAddedMsg)
+	if !ok || msg.Err != nil {
+		t.Fatalf("enter: got %+v, want quickAddedMsg", msg)
+	}
+	updated, _ = m.Update(msg)
+	m = updated.(model)
+	if m.ui.overlay != overlayNone || m.ui.selected != expensesBox {
+		t.Errorf("after adding: overlay=%v selected=%v, want closed on the expenses box", m.ui.overlay, m.ui.selected)
+	}
+	list, err := store.ListExpenses(time.Now().AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("ListExpenses: %v", err)
+	}
+	var found bool
+	for _, e := range list {
+		found = found || (e.Amount == 15 && e.Description == "coffee" && e.Type == types.ExpenseTypeFood)
+	}
+	if !found {
+		t.Errorf("after adding: got %+v, want 15.00 coffee food", list)
+	}
+
+	// Esc cancels
+	typeText(":9 tea")
+	updated, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	m = updated.(model)
+	if m.ui.overlay != overlayNone || m.ui.selected != expensesBox {
+		t.Errorf("esc: overlay=%v selected=%v, want closed on the expenses box", m.ui.overlay, m.ui.selected)
+	}
+}
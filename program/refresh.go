@@ -0,0 +1,59 @@
+package program
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kyawphyothu/sana/database"
+)
+
+// The TUI reloads by itself when the database changes from outside, such as a
+// `sana add` in another terminal: it polls the store's data version and reloads
+// when it moves. The reload keeps the selected expense selected (see applyFilter)
+// and the lists scrolled where they were.
+
+// dataVersionPollInterval is how often the data version is checked.
+const dataVersionPollInterval = 2 * time.Second
+
+// dataVersionMsg is sent by readDataVersion and watchDataVersion with the data version of store.
+type dataVersionMsg struct {
+	Store   database.Store
+	Version int64
+	Err     error
+}
+
+// readDataVersion returns a command that reads the data version of store, starting
+// its poll: each dataVersionMsg of the active store schedules the next read (see
+// watchDataVersion), and the poll of a store no longer active ends.
+func readDataVersion(store database.Store) tea.Cmd {
+	return func() tea.Msg {
+		version, err := store.DataVersion()
+		return dataVersionMsg{Store: store, Version: version, Err: err}
+	}
+}
+
+// watchDataVersion returns a command that reads the data version of store after
+// dataVersionPollInterval.
+func watchDataVersion(store database.Store) tea.Cmd {
+	return tea.Tick(dataVersionPollInterval, func(time.Time) tea.Msg {
+		return readDataVersion(store)()
+	})
+}
+
+// handleDataVersion reloads the data when the data version has moved since the last poll.
+func (m model) handleDataVersion(msg dataVersionMsg) (tea.Model, tea.Cmd) {
+	if msg.Store != m.store {
+		return m, nil
+	}
+	next := watchDataVersion(m.store)
+	// A failed read is retried at the next poll; loads report their own errors
+	if msg.Err != nil {
+		return m, next
+	}
+	if !m.data.dataVersionKnown || msg.Version == m.data.dataVersion {
+		m.data.dataVersion, m.data.dataVersionKnown = msg.Version, true
+		return m, next
+	}
+	m.data.dataVersion = msg.Version
+	return m, tea.Batch(m.reloadAllData(), next)
+}
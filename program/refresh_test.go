@@ -0,0 +1,126 @@
+package program
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kyawphyothu/sana/config"
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestRefreshOnDataVersion(t *testing.T) {
+	store := database.NewMemoryStore()
+	now := time.Now()
+	for i := range 20 {
+		if _, err := store.CreateExpense(now.Add(-time.Duration(i+1)*time.Minute), float64(i+1), "item", types.ExpenseTypeFood); err != nil {
+			t.Fatalf("CreateExpense: %v", err)
+		}
+	}
+	m := InitialModel(store, "default")
+	m.ui.width, m.ui.height = 100, 30
+	updated, _ := m.Update(loadMonthData(store, now)())
+	m = updated.(model)
+	m.ui.expensesList.SetLength(len(m.expenseRows()))
+	for range 12 {
+		m.moveRowDown(m.expensesMaxVisibleRows())
+	}
+	selected, offset := m.selectedExpenseID(), m.ui.expensesList.ScrollOffset()
+	if offset == 0 {
+		t.Fatalf("setup: want the expenses list scrolled, offset %d", offset)
+	}
+
+	// The first poll only notes the version
+	updated, cmd := m.Update(readDataVersion(store)())
+	m = updated.(model)
+	if !m.data.dataVersionKnown || cmd == nil {
+		t.Fatalf("first poll: known %v, cmd %v; want the version noted and the next poll", m.data.dataVersionKnown, cmd)
+	}
+
+	// Another process adds an expense, dated before the selected one so the rows
+	// above it stay the same
+	if _, err := store.CreateExpense(now.Add(-time.Hour), 99, "from elsewhere", types.ExpenseTypeBills); err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+	version, _ := store.DataVersion()
+	updated, cmd = m.Update(readDataVersion(store)())
+	m = updated.(model)
+	if cmd == nil || m.data.dataVersion != version {
+		t.Fatalf("poll after a change: version %d, cmd %v; want %d and the reload", m.data.dataVersion, cmd, version)
+	}
+	updated, _ = m.Update(loadMonthData(store, m.ui.activeMonth)())
+	m = updated.(model)
+	if len(m.data.expenses) != 21 {
+		t.Fatalf("after the reload: got %d expenses, want 21", len(m.data.expenses))
+	}
+	if m.selectedExpenseID() != selected || m.ui.expensesList.ScrollOffset() != offset {
+		t.Errorf("after the reload: selected %d at offset %d, want %d at %d", m.selectedExpenseID(), m.ui.expensesList.ScrollOffset(), selected, offset)
+	}
+
+	// The poll of a store that is no longer active ends
+	if _, cmd := m.Update(readDataVersion(database.NewMemoryStore())()); cmd != nil {
+		t.Error("poll of another store: got a command, want none")
+	}
+}
+
+// TestRefreshFromAnotherProcess writes through a second handle on the same database
+// file, as `sana add` in another terminal does, for a plain and an encrypted database.
+func TestRefreshFromAnotherProcess(t *testing.T) {
+	opens := map[string]func(cfg *config.Config) (*sql.DB, error){
+		"sqlite": func(cfg *config.Config) (*sql.DB, error) {
+			return sql.Open("sqlite", cfg.DBPath)
+		},
+		"encrypted": func(cfg *config.Config) (*sql.DB, error) {
+			return database.NewEncryptedDB(cfg, "pw")
+		},
+	}
+	for name, open := range opens {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sana.db")
+			cfg := &config.Config{DBPath: path, EncryptedDBPath: path + config.EncryptedExt}
+			if name == "encrypted" {
+				if err := database.EncryptDatabase(path, cfg.EncryptedDBPath, "pw"); err != nil {
+					t.Fatalf("EncryptDatabase: %v", err)
+				}
+			}
+			var stores []database.Store
+			for range 2 {
+				db, err := open(cfg)
+				if err != nil {
+					t.Fatalf("open: %v", err)
+				}
+				if err := database.Migrate(db); err != nil {
+					t.Fatalf("migrate: %v", err)
+				}
+				store := database.NewSQLiteStore(db)
+				defer store.Close()
+				stores = append(stores, store)
+			}
+			tui, other := stores[0], stores[1]
+
+			m := InitialModel(tui, "default")
+			updated, _ := m.Update(readDataVersion(tui)())
+			m = updated.(model)
+			before := m.data.dataVersion
+			if _, cmd := m.Update(readDataVersion(tui)()); cmd == nil || m.data.dataVersion != before {
+				t.Fatalf("poll without changes: version %d, want %d", m.data.dataVersion, before)
+			}
+
+			if _, err := other.CreateExpense(time.Now(), 7, "from elsewhere", types.ExpenseTypeFood); err != nil {
+				t.Fatalf("CreateExpense: %v", err)
+			}
+			updated, _ = m.Update(readDataVersion(tui)())
+			m = updated.(model)
+			if m.data.dataVersion == before {
+				t.Fatalf("poll after another process's write: version still %d", before)
+			}
+			updated, _ = m.Update(loadMonthData(tui, m.ui.activeMonth)())
+			m = updated.(model)
+			if len(m.data.expenses) != 1 || m.data.expenses[0].Description != "from elsewhere" {
+				t.Errorf("after the reload: got %+v, want the other process's expense", m.data.expenses)
+			}
+		})
+	}
+}
@@ -74,6 +74,32 @@ func (s *scrollableList) moveToBottom(maxVisible int) {
 	s.adjustScrollOffset(maxVisible)
 }
 
+// selectRow selects row i (clamped to the list) and scrolls it into view.
+func (s *scrollableList) selectRow(i, maxVisible int) {
+	if i >= s.Len() {
+		i = s.Len() - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	s.selectedRow = i
+	if s.selectedRow < s.scrollOffset {
+		s.scrollOffset = s.selectedRow
+	}
+	s.adjustScrollOffset(maxVisible)
+}
+
+// scroll moves the viewport by delta rows (negative is up) without going past either
+// end, and moves the selection along when it would leave the viewport.
+// maxVisible is the number of rows visible in the viewport.
+func (s *scrollableList) scroll(delta, maxVisible int) {
+	if maxVisible <= 0 || s.Len() == 0 {
+		return
+	}
+	s.scrollOffset = max(0, min(s.scrollOffset+delta, s.Len()-maxVisible))
+	s.selectedRow = max(s.scrollOffset, min(s.selectedRow, s.scrollOffset+maxVisible-1))
+}
+
 // reset sets selection and scroll to zero (e.g. when data is reloaded).
 func (s *scrollableList) reset() {
 	s.selectedRow = 0
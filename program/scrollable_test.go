@@ -80,3 +80,45 @@ func TestScrollableList_moveDownAtBottomNoOp(t *testing.T) {
 		t.Errorf("moveDown at bottom should stay at 4, got %d", s.SelectedRow())
 	}
 }
+
+func TestSelectRow(t *testing.T) {
+	s := scrollableList{selectedRow: 0, scrollOffset: 0}
+	s.SetLength(20)
+	s.selectRow(12, 5)
+	if s.SelectedRow() != 12 || s.ScrollOffset() != 8 {
+		t.Errorf("selectRow(12): got row %d offset %d, want 12, 8", s.SelectedRow(), s.ScrollOffset())
+	}
+	s.selectRow(3, 5)
+	if s.SelectedRow() != 3 || s.ScrollOffset() != 3 {
+		t.Errorf("selectRow(3): got row %d offset %d, want 3, 3", s.SelectedRow(), s.ScrollOffset())
+	}
+	s.selectRow(99, 5)
+	if s.SelectedRow() != 19 {
+		t.Errorf("selectRow(99): got row %d, want 19 (clamped)", s.SelectedRow())
+	}
+}
+
+func TestScroll(t *testing.T) {
+	s := scrollableList{}
+	s.SetLength(20)
+	s.scroll(3, 5)
+	if s.SelectedRow() != 3 || s.ScrollOffset() != 3 {
+		t.Errorf("scroll(3): got row %d offset %d, want 3, 3", s.SelectedRow(), s.ScrollOffset())
+	}
+	s.scroll(99, 5)
+	if s.SelectedRow() != 15 || s.ScrollOffset() != 15 {
+		t.Errorf("scroll(99): got row %d offset %d, want 15, 15", s.SelectedRow(), s.ScrollOffset())
+	}
+	s.selectRow(19, 5)
+	s.scroll(-2, 5)
+	if s.SelectedRow() != 17 || s.ScrollOffset() != 13 {
+		t.Errorf("scroll(-2): got row %d offset %d, want 17, 13", s.SelectedRow(), s.ScrollOffset())
+	}
+
+	short := scrollableList{}
+	short.SetLength(3)
+	short.scroll(1, 5)
+	if short.ScrollOffset() != 0 {
+		t.Errorf("scroll on a list shorter than the viewport: got offset %d, want 0", short.ScrollOffset())
+	}
+}
@@ -0,0 +1,127 @@
+package program
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kyawphyothu/sana/config"
+	"github.com/kyawphyothu/sana/types"
+)
+
+// expenseSortKey is the column the expenses box is sorted by.
+type expenseSortKey int
+
+const (
+	sortByDate expenseSortKey = iota
+	sortByAmount
+	sortByCategory
+	sortByDescription
+	numExpenseSortKeys
+)
+
+var expenseSortKeyNames = [numExpenseSortKeys]string{"date", "amount", "category", "description"}
+
+// expenseSort is the expenses box sort order. The zero value (date, newest first)
+// matches the order the store returns expenses in.
+type expenseSort struct {
+	key expenseSortKey
+	asc bool
+}
+
+// String returns the sort as stored in settings, e.g. "amount desc".
+func (s expenseSort) String() string {
+	dir := "desc"
+	if s.asc {
+		dir = "asc"
+	}
+	return expenseSortKeyNames[s.key] + " " + dir
+}
+
+// parseExpenseSort parses a sort saved by String. Unknown values yield the default sort.
+func parseExpenseSort(s string) expenseSort {
+	name, dir, _ := strings.Cut(strings.TrimSpace(s), " ")
+	for i, n := range expenseSortKeyNames {
+		if n == name {
+			return expenseSort{key: expenseSortKey(i), asc: dir == "asc"}
+		}
+	}
+	return expenseSort{}
+}
+
+// next returns the sort by the next column, keeping the direction.
+func (s expenseSort) next() expenseSort {
+	s.key = (s.key + 1) % numExpenseSortKeys
+	return s
+}
+
+// reversed returns the sort with the opposite direction.
+func (s expenseSort) reversed() expenseSort {
+	s.asc = !s.asc
+	return s
+}
+
+// sortExpenses sorts expenses in place. Ties are broken by date and then ID,
+// so re-sorting the same expenses always gives the same order.
+func sortExpenses(expenses []types.Expense, s expenseSort) {
+	slices.SortFunc(expenses, func(a, b types.Expense) int {
+		var c int
+		switch s.key {
+		case sortByAmount:
+			c = cmp.Compare(a.Amount, b.Amount)
+		case sortByCategory:
+			c = strings.Compare(strings.ToLower(a.Type.String()), strings.ToLower(b.Type.String()))
+		case sortByDescription:
+			c = strings.Compare(strings.ToLower(a.Description), strings.ToLower(b.Description))
+		}
+		if c == 0 {
+			c = a.Date.Compare(b.Date)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if !s.asc {
+			c = -c
+		}
+		return c
+	})
+}
+
+// setExpenseSort re-sorts the loaded expenses, keeping the selected expense selected.
+func (m *model) setExpenseSort(s expenseSort) {
+	m.ui.expenseSort = s
+	sortExpenses(m.data.allExpenses, s)
+	m.applyFilter()
+}
+
+// settingsLoadedMsg is sent when the saved settings have been read at startup.
+type settingsLoadedMsg struct {
+	Settings *config.Settings
+	Err      error
+}
+
+// settingsSavedMsg is sent when a changed setting has been written (success or error).
+type settingsSavedMsg struct {
+	Err error
+}
+
+// loadSettings returns a command that reads the saved settings.
+func loadSettings() tea.Cmd {
+	return func() tea.Msg {
+		settings, err := config.LoadSettings()
+		return settingsLoadedMsg{Settings: settings, Err: err}
+	}
+}
+
+// saveExpenseSort returns a command that remembers s as the expenses box sort.
+func saveExpenseSort(s expenseSort) tea.Cmd {
+	return func() tea.Msg {
+		settings, err := config.LoadSettings()
+		if err != nil {
+			return settingsSavedMsg{Err: err}
+		}
+		settings.ExpenseSort = s.String()
+		return settingsSavedMsg{Err: config.SaveSettings(settings)}
+	}
+}
@@ -0,0 +1,62 @@
+package program
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestSortExpenses(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 12, 0, 0, 0, time.Local) }
+	base := []types.Expense{
+		{ID: 1, Date: day(1), Amount: 30, Description: "rent", Type: types.ExpenseTypeBills},
+		{ID: 2, Date: day(2), Amount: 5, Description: "Coffee", Type: types.ExpenseTypeFood},
+		{ID: 3, Date: day(2), Amount: 30, Description: "bus", Type: types.ExpenseTypeTransport},
+	}
+	tests := []struct {
+		sort expenseSort
+		want []int64
+	}{
+		{expenseSort{}, []int64{3, 2, 1}},
+		{expenseSort{asc: true}, []int64{1, 2, 3}},
+		{expenseSort{key: sortByAmount}, []int64{3, 1, 2}},
+		{expenseSort{key: sortByAmount, asc: true}, []int64{2, 1, 3}},
+		{expenseSort{key: sortByDescription, asc: true}, []int64{3, 2, 1}},
+		{expenseSort{key: sortByCategory, asc: true}, []int64{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		expenses := append([]types.Expense(nil), base...)
+		sortExpenses(expenses, tt.sort)
+		var got []int64
+		for _, e := range expenses {
+			got = append(got, e.ID)
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("sortExpenses(%s): got %v, want %v", tt.sort, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseExpenseSort(t *testing.T) {
+	tests := []struct {
+		in   string
+		want expenseSort
+	}{
+		{"", expenseSort{}},
+		{"amount desc", expenseSort{key: sortByAmount}},
+		{"description asc", expenseSort{key: sortByDescription, asc: true}},
+		{"bogus asc", expenseSort{}},
+	}
+	for _, tt := range tests {
+		if got := parseExpenseSort(tt.in); got != tt.want {
+			t.Errorf("parseExpenseSort(%q): got %s, want %s", tt.in, got, tt.want)
+		}
+		if tt.in != "" && tt.in != "bogus asc" && parseExpenseSort(tt.in).String() != tt.in {
+			t.Errorf("parseExpenseSort(%q).String(): got %q", tt.in, parseExpenseSort(tt.in).String())
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package program
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kyawphyothu/sana/types"
+)
+
+// The status bar is the last line of the screen. It shows the latest message (what
+// an action did, or why a load failed) until it expires, and the key hints of the
+// current scope otherwise; on the right it always shows the active month, how many
+// expenses are shown and the filter.
+
+// statusKind is the kind of a status message, which sets its color and how long it stays.
+type statusKind int
+
+const (
+	statusInfo statusKind = iota
+	statusSuccess
+	statusError
+)
+
+// How long a status message stays; errors stay longer to be read
+const (
+	statusTimeout      = 4 * time.Second
+	statusErrorTimeout = 8 * time.Second
+)
+
+// statusMessage is a message in the status bar.
+type statusMessage struct {
+	kind statusKind
+	text string
+	id   int // tells a message from the one that replaced it when its timer fires
+}
+
+// statusExpiredMsg is sent when the status message with id has been shown long enough.
+type statusExpiredMsg struct {
+	id int
+}
+
+// setStatus shows text in the status bar and returns the command that dismisses it.
+func (m *model) setStatus(kind statusKind, text string) tea.Cmd {
+	m.ui.statusID++
+	m.ui.status = statusMessage{kind: kind, text: text, id: m.ui.statusID}
+	timeout := statusTimeout
+	if kind == statusError {
+		timeout = statusErrorTimeout
+	}
+	id := m.ui.statusID
+	return tea.Tick(timeout, func(time.Time) tea.Msg { return statusExpiredMsg{id: id} })
+}
+
+// setStatusErr shows err in the status bar.
+func (m *model) setStatusErr(err error) tea.Cmd {
+	return m.setStatus(statusError, "Error: "+err.Error())
+}
+
+// expireStatus clears the status message with id, unless another one has replaced it.
+func (m *model) expireStatus(id int) {
+	if m.ui.status.id == id {
+		m.ui.status = statusMessage{}
+	}
+}
+
+// expenseAddedStatus describes a new expense, e.g. "Added expense #123 (25.50 Food)".
+func expenseAddedStatus(id int64, amount float64, t types.ExpenseType) string {
+	return fmt.Sprintf("Added expense #%d (%s %s)", id, formatAmountWithCommas(amount), t.String())
+}
+
+// countExpenses returns "1 expense" or "n expenses".
+func countExpenses(n int) string {
+	if n == 1 {
+		return "1 expense"
+	}
+	return fmt.Sprintf("%d expenses", n)
+}
+
+// statusContext returns the right side of the status bar: the active month, the
+// number of expenses shown, the filter and the marks.
+func (m model) statusContext() string {
+	parts := []string{m.ui.activeMonth.Format("January 2006")}
+	if m.ui.filter.isEmpty() {
+		parts = append(parts, countExpenses(len(m.data.allExpenses)))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d of %s", len(m.data.expenses), countExpenses(len(m.data.allExpenses))),
+			"filter: "+m.ui.filterQuery)
+	}
+	if n := len(m.markedExpenses()); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d marked", n))
+	}
+	return strings.Join(parts, " • ")
+}
+
+// renderStatusBar renders the status bar, the width of the screen.
+func (m model) renderStatusBar() string {
+	var left string
+	switch m.ui.status.kind {
+	case statusSuccess:
+		left = m.styles.Line.Foreground(m.styles.Theme.Success).Render(m.ui.status.text)
+	case statusError:
+		left = m.styles.Line.Foreground(m.styles.Theme.Error).Render(m.ui.status.text)
+	default:
+		left = m.styles.Line.Render(m.ui.status.text)
+	}
+	if m.ui.status.text == "" {
+		left = m.renderHints()
+	}
+	left = m.styles.Line.Render(" ") + left
+
+	// The context gives way to the message or hints on a narrow screen
+	right := m.styles.Line.Render("  ") + m.styles.Muted.Render(m.statusContext()) + m.styles.Line.Render(" ")
+	if ansi.StringWidth(right)+statusMinLeftWidth > m.ui.width {
+		right = ""
+	}
+	leftWidth := m.ui.width - ansi.StringWidth(right)
+	left = ansi.Truncate(left, leftWidth, "…")
+	if pad := leftWidth - ansi.StringWidth(left); pad > 0 {
+		left += m.styles.Line.Render(strings.Repeat(" ", pad))
+	}
+	return left + right
+}
@@ -0,0 +1,100 @@
+package program
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/types"
+)
+
+func TestStatusBar(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.activeMonth = time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	m.ui.selected = summaryBox
+	bar := ansi.Strip(m.renderStatusBar())
+	if lipgloss.Width(bar) != m.ui.width || !strings.Contains(bar, "space detail") || strings.Contains(bar, "mark") {
+		t.Errorf("renderStatusBar (summary): got %q, want the summary hints at width %d", bar, m.ui.width)
+	}
+	if !strings.HasSuffix(bar, "March 2025 • 0 expenses ") {
+		t.Errorf("renderStatusBar: got %q, want the month and count on the right", bar)
+	}
+
+	// The month and count give way on a narrow screen
+	m.ui.width = 30
+	if bar := ansi.Strip(m.renderStatusBar()); lipgloss.Width(bar) != 30 || !strings.HasSuffix(strings.TrimSpace(bar), "…") || strings.Contains(bar, "March") {
+		t.Errorf("renderStatusBar (narrow): got %q, want the hints cut off at 30 with …", bar)
+	}
+}
+
+func TestStatusContext(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.activeMonth = time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	m.data.allExpenses = []types.Expense{
+		{ID: 1, Description: "coffee", Type: types.ExpenseTypeFood, Amount: 4},
+		{ID: 2, Description: "taxi", Type: types.ExpenseTypeTransport, Amount: 12},
+		{ID: 3, Description: "coffee beans", Type: types.ExpenseTypeFood, Amount: 9},
+	}
+	m.applyFilter()
+	if got, want := m.statusContext(), "March 2025 • 3 expenses"; got != want {
+		t.Errorf("statusContext: got %q, want %q", got, want)
+	}
+
+	m.setFilterQuery("coffee")
+	m.ui.marked = map[int64]bool{1: true}
+	if got, want := m.statusContext(), "March 2025 • 2 of 3 expenses • filter: coffee • 1 marked"; got != want {
+		t.Errorf("statusContext (filtered): got %q, want %q", got, want)
+	}
+}
+
+func TestStatusMessages(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.width, m.ui.height = 100, 30
+
+	// A failed load shows in the status bar whichever box is selected
+	m.ui.selected = monthlyReportBox
+	updated, cmd := m.Update(monthlyReportLoadedMsg{Err: errors.New("disk I/O error")})
+	m = updated.(model)
+	if cmd == nil || !strings.Contains(ansi.Strip(m.renderStatusBar()), "Error: disk I/O error") {
+		t.Fatalf("load error: got %q and cmd %v, want the error with a timer", ansi.Strip(m.renderStatusBar()), cmd)
+	}
+	first := m.ui.status.id
+
+	updated, _ = m.Update(expenseCreatedMsg{ID: 123, Amount: 25.5, Type: types.ExpenseTypeFood})
+	m = updated.(model)
+	if bar := ansi.Strip(m.renderStatusBar()); !strings.Contains(bar, "Added expense #123 (25.50 Food)") {
+		t.Errorf("expense created: got %q, want the new expense", bar)
+	}
+
+	// An earlier message's timer leaves the later message alone
+	updated, _ = m.Update(statusExpiredMsg{id: first})
+	m = updated.(model)
+	if m.ui.status.text == "" {
+		t.Error("expired earlier message: the later message was dismissed")
+	}
+	updated, _ = m.Update(statusExpiredMsg{id: m.ui.status.id})
+	m = updated.(model)
+	if m.ui.status.text != "" || !strings.Contains(ansi.Strip(m.renderStatusBar()), "detail") {
+		t.Errorf("expired message: got %q, want the key hints back", ansi.Strip(m.renderStatusBar()))
+	}
+
+	for _, tt := range []struct {
+		msg  tea.Msg
+		want string
+	}{
+		{expenseDeletedMsg{IDs: []int64{7}}, "Deleted expense #7"},
+		{expenseDeletedMsg{IDs: []int64{7, 8}}, "Deleted 2 expenses"},
+		{expensesRecategorizedMsg{Count: 1, Type: types.ExpenseTypeBills}, "Moved 1 expense to Bills"},
+	} {
+		updated, _ := m.Update(tt.msg)
+		if got := updated.(model).ui.status.text; got != tt.want {
+			t.Errorf("Update(%T): status %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
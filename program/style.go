@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	lipgloss "charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Theme colors - centralized color palette
@@ -249,6 +250,10 @@ func (s Styles) buildTopBorder(width int, borderChars BorderChars, title string,
 	if bold {
 		titleStyle = titleStyle.Bold(true)
 	}
+	// Cut a title too long for the border (e.g. in the compact layout)
+	if maxTitleWidth := width - borderCornerCharsWidth - 3; lipgloss.Width(title) > maxTitleWidth {
+		title = ansi.Truncate(title, max(maxTitleWidth, 0), "…")
+	}
 	titleText := titleStyle.Render(title)
 
 	// Add spacing around title: "─ Title ─"
@@ -355,3 +360,20 @@ func CategoryColorSelected(category string) color.Color {
 		return lipgloss.Color("#5F657A") // same as Other for contrast on primary
 	}
 }
+
+// HeatmapColor returns the shade of a calendar day at the given heat level,
+// from 0 (no expenses) to numHeatLevels-1 (the month's busiest days)
+func HeatmapColor(level int) color.Color {
+	switch level {
+	case 1:
+		return lipgloss.Color("#0E4429") // dark green
+	case 2:
+		return lipgloss.Color("#006D32") // green
+	case 3:
+		return lipgloss.Color("#26A641") // bright green
+	case 4:
+		return lipgloss.Color("#39D353") // brightest green
+	default:
+		return lipgloss.Color("#1E2030") // empty day, just above the background
+	}
+}
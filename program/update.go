@@ -1,8 +1,13 @@
 package program
 
 import (
+	"fmt"
+	"strconv"
+	"time"
+
 	tea "charm.land/bubbletea/v2"
-	"github.com/kyawphyothu/sana/database"
+	"github.com/kyawphyothu/sana/expense"
+	"github.com/kyawphyothu/sana/types"
 )
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -13,23 +18,55 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case monthDataLoadedMsg:
-		if msg.Err != nil {
-			m.ui.err = msg.Err
+		// Drop results for a month we've already navigated away from
+		if !msg.Month.IsZero() && !sameMonth(msg.Month, m.ui.activeMonth) {
 			return m, nil
 		}
-		m.ui.err = nil
-		m.data.expenses = msg.Expenses
-		m.data.summary = msg.Summary
-		m.data.total = msg.Total
-		m.clampSelections()
+		if msg.Err != nil {
+			return m, m.setStatusErr(msg.Err)
+		}
+		sortExpenses(msg.Expenses, m.ui.expenseSort)
+		m.data.allExpenses = msg.Expenses
+		m.data.allSummary = msg.Summary
+		m.data.allTotal = msg.Total
+		m.data.daily = msg.Daily
+		m.applyFilter()
+		m.pruneMarks()
+		if m.ui.overlay == overlayExpenseDetail {
+			// Keep the detail's expense selected so delete acts on it
+			m.selectExpenseByID(m.ui.detailExpense.ID)
+		}
+		return m, nil
+
+	case settingsLoadedMsg:
+		if msg.Err != nil {
+			return m, m.setStatusErr(msg.Err)
+		}
+		m.setExpenseSort(parseExpenseSort(msg.Settings.ExpenseSort))
+		return m, nil
+
+	case settingsSavedMsg:
+		if msg.Err != nil {
+			return m, m.setStatusErr(msg.Err)
+		}
+		return m, nil
+
+	case descriptionHistoryLoadedMsg:
+		if msg.Err != nil {
+			return m, m.setStatusErr(msg.Err)
+		}
+		m.data.descriptionHistory = msg.History
+		descriptions := make([]string, len(msg.History))
+		for i, h := range msg.History {
+			descriptions[i] = h.Description
+		}
+		m.form.description.SetSuggestions(descriptions)
 		return m, nil
 
 	case monthlyReportLoadedMsg:
 		if msg.Err != nil {
-			m.ui.err = msg.Err
-			return m, nil
+			return m, m.setStatusErr(msg.Err)
 		}
-		m.ui.err = nil
 		m.data.monthlyReport = msg.MonthlyReport
 		m.clampSelections()
 		return m, nil
@@ -43,7 +80,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.addFormReset()
 		m.ui.previousSelected = m.ui.selected
 		m.ui.selected = expensesBox
-		return m, m.reloadAllData()
+		return m, tea.Batch(m.reloadAllData(), m.setStatus(statusSuccess, expenseAddedStatus(msg.ID, msg.Amount, msg.Type)))
+
+	case quickAddedMsg:
+		if msg.Err != nil {
+			m.ui.err = msg.Err
+			return m, nil
+		}
+		m.closeQuickAdd()
+		return m, tea.Batch(m.reloadAllData(), m.setStatus(statusSuccess, expenseAddedStatus(msg.ID, msg.Amount, msg.Type)))
 
 	case expenseDeletedMsg:
 		if msg.Err != nil {
@@ -52,17 +97,86 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.ui.err = nil
+		m.clearMarks()
 		m.ui.previousSelected = m.ui.selected
+		m.closeExpenseOverlay()
+		deleted := "Deleted " + countExpenses(len(msg.IDs))
+		if len(msg.IDs) == 1 {
+			deleted = fmt.Sprintf("Deleted expense #%d", msg.IDs[0])
+		}
+		return m, tea.Batch(m.reloadAllData(), m.setStatus(statusSuccess, deleted))
+
+	case expensesRecategorizedMsg:
+		if msg.Err != nil {
+			m.ui.err = msg.Err
+			return m, nil
+		}
+		m.ui.err = nil
+		m.clearMarks()
 		m.ui.selected = expensesBox
 		m.ui.overlay = overlayNone
+		changed := fmt.Sprintf("Moved %s to %s", countExpenses(msg.Count), msg.Type.String())
+		return m, tea.Batch(m.reloadAllData(), m.setStatus(statusSuccess, changed))
+
+	case expenseDuplicatedMsg:
+		if msg.Err != nil {
+			m.ui.err = msg.Err
+			return m, nil
+		}
+		m.ui.err = nil
+		m.ui.detailNotice = fmt.Sprintf("Duplicated as #%d", msg.ID)
 		return m, m.reloadAllData()
 
+	case profileSwitchedMsg:
+		if msg.Err != nil {
+			m.ui.err = msg.Err
+			m.unlock.unlocking = false
+			m.unlock.passphrase.SetValue("")
+			return m, nil
+		}
+		if m.store != nil {
+			m.store.Close()
+		}
+		m.store = msg.Store
+		m.profile = msg.Profile
+		m.unlock = unlockForm{}
+		m.ui.err = nil
+		m.ui.selected = expensesBox
+		m.ui.overlay = overlayNone
+		m.ui.activeMonth = startOfMonth(time.Now())
+		m.resetRowSelection()
+		m.clearMarks()
+		m.data.dataVersionKnown = false
+		return m, tea.Batch(m.reloadAllData(), readDataVersion(m.store), m.setStatus(statusInfo, fmt.Sprintf("Switched to profile %q", msg.Profile)))
+
+	case profileLockedMsg:
+		// The active profile stays open until the passphrase opens the new one
+		// (profileSwitchedMsg), so esc can go back to it
+		m.unlock = newUnlockForm(msg.Profile, m.styles.Theme)
+		m.ui.err = nil
+		m.ui.selected = expensesBox
+		m.ui.overlay = overlayNone
+		return m, nil
+
 	case formValidationErrMsg:
 		m.ui.err = msg.Err
 		return m, nil
 
+	case dataVersionMsg:
+		return m.handleDataVersion(msg)
+
+	case statusExpiredMsg:
+		m.expireStatus(msg.id)
+		return m, nil
+
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
+
+	case tea.MouseClickMsg:
+		return m.handleMouseClick(msg)
+
+	case tea.MouseWheelMsg:
+		return m.handleMouseWheel(msg)
 	}
 
 	return m, nil
@@ -74,9 +188,24 @@ func (m model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	}
 
+	if m.isLocked() {
+		return m.handleUnlockKeys(msg)
+	}
+	// Overlays come first: the box they were opened from stays selected in some flows
+	if m.ui.overlay != overlayNone {
+		return m.handleOverlayKeys(msg)
+	}
 	if m.ui.selected == addBox {
 		return m.handleAddBoxKeys(msg)
 	}
+	if m.ui.selected == expensesBox && m.ui.filtering {
+		return m.handleFilterBarKeys(msg)
+	}
+	if isBoxScope(m.keyScope()) {
+		if updated, cmd, handled := m.handleGlobalKeys(msg); handled {
+			return updated, cmd
+		}
+	}
 	if m.ui.selected == expensesBox {
 		return m.handleExpensesBoxKeys(msg)
 	}
@@ -86,64 +215,181 @@ func (m model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.ui.selected == summaryBox {
 		return m.handleSummaryBoxKeys(msg)
 	}
-	if m.ui.overlay != overlayNone {
-		return m.handleOverlayKeys(msg)
-	}
 
 	return m, nil
 }
 
-// handleExpensesBoxKeys handles keys for the expenses box.
-func (m model) handleExpensesBoxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+// handleGlobalKeys handles the keys that work the same in every box (scopeGlobal in
+// the keymap). handled is false for any other key, which is left to the box.
+func (m model) handleGlobalKeys(msg tea.KeyMsg) (updated tea.Model, cmd tea.Cmd, handled bool) {
 	switch msg.String() {
-	case "d":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = confirmDeleteOverlay
-		m.ui.overlay = overlayConfirmDelete
-		return m, nil
-	case "?":
-		return m.help()
+	case "?", "f1":
+		updated, cmd = m.help()
+	case "[":
+		updated, cmd = m.setActiveMonth(m.ui.activeMonth.AddDate(0, -1, 0))
+	case "]":
+		updated, cmd = m.setActiveMonth(m.ui.activeMonth.AddDate(0, 1, 0))
+	case ".":
+		updated, cmd = m.setActiveMonth(time.Now())
+	case "M":
+		updated, cmd = m.openGoToMonth()
+	case ":":
+		updated, cmd = m.openQuickAdd()
+	case "tab":
+		updated, cmd = m.cycleBox(1)
+	case "shift+tab":
+		updated, cmd = m.cycleBox(-1)
+	case "C":
+		updated, cmd = m.openCalendar()
+	case "p":
+		updated, cmd = m.openProfiles()
 	case "q":
-		return m, tea.Quit
+		updated, cmd = m, tea.Quit
 	case "r":
 		m.resetRowSelection()
-		return m, loadMonthData(m.db, m.ui.activeMonth)
+		updated, cmd = m, loadMonthData(m.store, m.ui.activeMonth)
 	case "a":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = addBox
-		return m, nil
+		updated, cmd = m.openAddForm()
+	case "e":
+		m.selectBox(expensesBox)
+		updated = m
 	case "s":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = summaryBox
-		return m, nil
+		m.selectBox(summaryBox)
+		updated = m
 	case "m":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = monthlyReportBox
-		return m, nil
+		m.selectBox(monthlyReportBox)
+		updated = m
 	case "j", "down":
-		maxRows := m.calculateMaxVisibleRows()
-		m.moveRowDown(maxRows)
-		return m, nil
+		m.moveRowDown(m.calculateMaxVisibleRows())
+		updated = m
 	case "k", "up":
 		m.moveRowUp()
-		return m, nil
+		updated = m
 	case "g", "home":
 		m.moveRowToTop()
-		return m, nil
+		updated = m
 	case "G", "end":
-		maxRows := m.calculateMaxVisibleRows()
-		m.moveRowToBottom(maxRows)
+		m.moveRowToBottom(m.calculateMaxVisibleRows())
+		updated = m
+	default:
+		return m, nil, false
+	}
+	return updated, cmd, true
+}
+
+// handleExpensesBoxKeys handles the keys of the expenses box other than the
+// global ones (see handleGlobalKeys).
+func (m model) handleExpensesBoxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		if row, ok := m.selectedExpenseRow(); ok && row.header {
+			m.toggleDayCollapsed(row.date)
+			return m, nil
+		}
+		return m.openExpenseDetail()
+	case "d":
+		if len(m.bulkTargets()) == 0 {
+			return m, nil
+		}
+		m.ui.previousSelected = m.ui.selected
+		m.ui.selected = confirmDeleteOverlay
+		m.ui.overlay = overlayConfirmDelete
+		return m, nil
+	case "space":
+		if row, ok := m.selectedExpenseRow(); ok && row.header {
+			m.toggleDayCollapsed(row.date)
+			return m, nil
+		}
+		m.toggleMark()
+		return m, nil
+	case "z":
+		m.toggleGroupByDay()
+		return m, nil
+	case "Z":
+		if m.ui.groupByDay {
+			m.toggleAllDaysCollapsed()
+		}
+		return m, nil
+	case "v":
+		m.toggleMarkRange()
+		return m, nil
+	case "c":
+		return m.openRecategorize()
+	case "D":
+		return m.openDuplicate()
+	case "/":
+		m.ui.filtering = true
+		m.ui.filterInput.SetValue(m.ui.filterQuery)
+		m.ui.filterInput.CursorEnd()
+		return m, m.ui.filterInput.Focus()
+	case "esc":
+		// Cancel the v-range, then unmark, then clear the filter
+		switch {
+		case m.ui.markAnchor != 0:
+			m.ui.markAnchor = 0
+		case m.hasMarks():
+			m.clearMarks()
+		default:
+			m.clearFilter()
+		}
 		return m, nil
+	case "o":
+		m.setExpenseSort(m.ui.expenseSort.next())
+		return m, saveExpenseSort(m.ui.expenseSort)
+	case "O":
+		m.setExpenseSort(m.ui.expenseSort.reversed())
+		return m, saveExpenseSort(m.ui.expenseSort)
 	}
 	return m, nil
 }
 
+// handleFilterBarKeys handles the expenses filter bar while it is being edited:
+// the filter is applied as it is typed, enter keeps it and esc clears it.
+func (m model) handleFilterBarKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.keepFilter()
+		return m, nil
+	case "esc":
+		m.clearFilter()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.ui.filterInput, cmd = m.ui.filterInput.Update(msg)
+	m.setFilterQuery(m.ui.filterInput.Value())
+	return m, cmd
+}
+
+// handleUnlockKeys handles the passphrase prompt of a locked profile.
+func (m model) handleUnlockKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.unlock.unlocking {
+		return m, nil
+	}
+	switch msg.String() {
+	case "enter":
+		m.unlock.unlocking = true
+		m.ui.err = nil
+		return m, unlockProfile(m.unlock.profile, m.unlock.passphrase.Value())
+	case "esc":
+		if m.store == nil {
+			// Locked on start: there is no profile to go back to
+			return m, tea.Quit
+		}
+		m.unlock = unlockForm{}
+		m.ui.err = nil
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.unlock.passphrase, cmd = m.unlock.passphrase.Update(msg)
+	return m, cmd
+}
+
 // handleAddBoxKeys handles form navigation and forwards keys to the focused add-form input.
 // Call only when m.ui.selected == addBox.
 func (m model) handleAddBoxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "tab":
-		if m.form.typeCompleted {
+		if m.form.completed {
 			m.addFormFocusNext()
 			return m, nil
 		}
@@ -152,7 +398,10 @@ func (m model) handleAddBoxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			var cmd tea.Cmd
 			*in, cmd = in.Update(msg)
 			if m.isValueCompleteSuggestion() {
-				m.form.typeCompleted = true
+				m.form.completed = true
+				if m.form.focused == addFormDescription {
+					m.prefillFromHistory()
+				}
 			}
 			return m, cmd
 		}
@@ -164,6 +413,8 @@ func (m model) handleAddBoxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "shift+tab", "up":
 		m.addFormFocusPrev()
 		return m, nil
+	case "f1":
+		return m.help()
 	case "enter":
 		if cmd := m.addFormSubmit(); cmd != nil {
 			return m, cmd
@@ -178,101 +429,49 @@ func (m model) handleAddBoxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	in := m.addFormInput()
 	var cmd tea.Cmd
 	*in, cmd = in.Update(msg)
-	if m.form.focused == addFormType {
-		m.form.typeCompleted = false
+	if m.hasSuggestions() {
+		m.form.completed = false
 	}
 	return m, cmd
 }
 
-// handleSummaryBoxKeys handles keys for the summary box.
+// handleSummaryBoxKeys handles the keys of the summary box other than the
+// global ones (see handleGlobalKeys).
 func (m model) handleSummaryBoxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "space":
-		if m.ui.summaryList.SelectedRow() >= 0 && m.ui.summaryList.SelectedRow() < len(m.data.summary) {
-			m.ui.selected = categoryDetailOverlay
-			m.ui.overlay = overlayCategoryDetail
-		}
-		return m, nil
-	case "?":
-		return m.help()
-	case "q":
-		return m, tea.Quit
-	case "r":
-		m.resetRowSelection()
-		return m, loadMonthData(m.db, m.ui.activeMonth)
-	case "a":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = addBox
-		return m, nil
-	case "e":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = expensesBox
-		return m, nil
-	case "m":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = monthlyReportBox
-		return m, nil
-	case "j", "down":
-		maxRows := m.calculateMaxVisibleRows()
-		m.moveRowDown(maxRows)
-		return m, nil
-	case "k", "up":
-		m.moveRowUp()
-		return m, nil
-	case "g", "home":
-		m.moveRowToTop()
-		return m, nil
-	case "G", "end":
-		maxRows := m.calculateMaxVisibleRows()
-		m.moveRowToBottom(maxRows)
+		return m.openCategoryDetail()
+	case "b":
+		m.ui.summaryStackedBar = !m.ui.summaryStackedBar
 		return m, nil
 	}
 
 	return m, nil
 }
 
-// handleMonthlyReportBoxKeys handles keys for the monthly report box.
+// handleMonthlyReportBoxKeys handles the keys of the monthly report box other than the
+// global ones (see handleGlobalKeys).
 func (m model) handleMonthlyReportBoxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
 		selectedIdx := m.ui.monthlyReportList.SelectedRow()
 		if selectedIdx >= 0 && selectedIdx < len(m.data.monthlyReport) {
-			m.ui.activeMonth = m.data.monthlyReport[selectedIdx].Month
-			return m, loadMonthData(m.db, m.ui.activeMonth)
+			return m.setActiveMonth(m.data.monthlyReport[selectedIdx].Month)
 		}
 		return m, nil
-	case "?":
-		return m.help()
-	case "q":
-		return m, tea.Quit
-	case "r":
-		m.resetRowSelection()
-		return m, loadMonthData(m.db, m.ui.activeMonth)
-	case "a":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = addBox
-		return m, nil
-	case "e":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = expensesBox
+	case "b":
+		m.ui.reportView = (m.ui.reportView + 1) % numReportViews
 		return m, nil
-	case "s":
-		m.ui.previousSelected = m.ui.selected
-		m.ui.selected = summaryBox
-		return m, nil
-	case "j", "down":
-		maxRows := m.calculateMaxVisibleRows()
-		m.moveRowDown(maxRows)
-		return m, nil
-	case "k", "up":
-		m.moveRowUp()
-		return m, nil
-	case "g", "home":
-		m.moveRowToTop()
+	case "h", "left":
+		// Vertical bars run oldest to newest, so left is the next (older) row
+		if m.ui.reportView == reportVerticalBars {
+			m.moveRowDown(m.calculateMaxVisibleRows())
+		}
 		return m, nil
-	case "G", "end":
-		maxRows := m.calculateMaxVisibleRows()
-		m.moveRowToBottom(maxRows)
+	case "l", "right":
+		if m.ui.reportView == reportVerticalBars {
+			m.moveRowUp()
+		}
 		return m, nil
 	}
 
@@ -281,6 +480,17 @@ func (m model) handleMonthlyReportBoxKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // handleOverlayKeys dispatches key handling to the active overlay.
 func (m model) handleOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Help opens over any overlay; the prompts type "?", so there only f1 opens it
+	if m.ui.overlay != overlayHelp {
+		switch msg.String() {
+		case "f1":
+			return m.help()
+		case "?":
+			if m.ui.overlay != overlayGoToMonth && m.ui.overlay != overlayQuickAdd {
+				return m.help()
+			}
+		}
+	}
 	switch m.ui.overlay {
 	case overlayCategoryDetail:
 		return m.handleCategoryOverlayKeys(msg)
@@ -288,22 +498,140 @@ func (m model) handleOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleConfirmDeleteOverlayKeys(msg)
 	case overlayHelp:
 		return m.handleHelpOverlayKeys(msg)
+	case overlayProfiles:
+		return m.handleProfileOverlayKeys(msg)
+	case overlayGoToMonth:
+		return m.handleGoToMonthOverlayKeys(msg)
+	case overlayExpenseDetail:
+		return m.handleExpenseDetailOverlayKeys(msg)
+	case overlayRecategorize:
+		return m.handleRecategorizeOverlayKeys(msg)
+	case overlayCalendar:
+		return m.handleCalendarOverlayKeys(msg)
+	case overlayQuickAdd:
+		return m.handleQuickAddOverlayKeys(msg)
 	}
 	return m, nil
 }
 
-// handleCategoryOverlayKeys handles keys for the category detail overlay.
-func (m model) handleCategoryOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+// handleRecategorizeOverlayKeys handles the category picker for the marked (or selected) expenses.
+func (m model) handleRecategorizeOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	categories := types.AllExpenseTypes()
 	switch msg.String() {
-	case "space":
-		m.ui.overlay = overlayNone
-		m.ui.selected = summaryBox
+	case "j", "down":
+		m.ui.categoryList.moveDown(len(categories))
 		return m, nil
+	case "k", "up":
+		m.ui.categoryList.moveUp()
+		return m, nil
+	case "enter":
+		ids := expenseIDs(m.bulkTargets())
+		category := categories[m.ui.categoryList.SelectedRow()]
+		store := m.store
+		return m, func() tea.Msg {
+			return expensesRecategorizedMsg{Count: len(ids), Type: category, Err: store.UpdateExpensesType(ids, category)}
+		}
 	case "esc":
-		m.ui.selected = summaryBox
+		m.ui.selected = expensesBox
+		m.ui.overlay = overlayNone
+		m.ui.err = nil
+		return m, nil
+	case "q":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// handleCalendarOverlayKeys handles the calendar overlay: weeks run left to right and
+// weekdays top to bottom, and enter filters the expenses box to the selected day.
+func (m model) handleCalendarOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "j", "down":
+		m.moveCalendarDay(1)
+		return m, nil
+	case "k", "up":
+		m.moveCalendarDay(-1)
+		return m, nil
+	case "l", "right":
+		m.moveCalendarDay(7)
+		return m, nil
+	case "h", "left":
+		m.moveCalendarDay(-7)
+		return m, nil
+	case "g", "home":
+		m.ui.calendarDay = 1
+		return m, nil
+	case "G", "end":
+		m.ui.calendarDay = daysInMonth(m.ui.activeMonth)
+		return m, nil
+	case "[":
+		return m.setActiveMonth(m.ui.activeMonth.AddDate(0, -1, 0))
+	case "]":
+		return m.setActiveMonth(m.ui.activeMonth.AddDate(0, 1, 0))
+	case "enter":
+		m.filterToDay(m.ui.calendarDay)
+		m.ui.selected = expensesBox
+		m.ui.overlay = overlayNone
+		return m, nil
+	case "esc", "C":
+		m.ui.selected = m.ui.previousSelected
 		m.ui.overlay = overlayNone
 		m.ui.err = nil
 		return m, nil
+	case "q":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// handleCategoryOverlayKeys handles keys for the category detail overlay: its own
+// list of the category's expenses, and h/l to move through the categories.
+func (m model) handleCategoryOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "j", "down":
+		m.ui.categoryDetailList.SetLength(len(m.categoryExpenses()))
+		m.ui.categoryDetailList.moveDown(m.categoryDetailMaxRows())
+		return m, nil
+	case "k", "up":
+		m.ui.categoryDetailList.moveUp()
+		return m, nil
+	case "g", "home":
+		m.ui.categoryDetailList.moveToTop()
+		return m, nil
+	case "G", "end":
+		m.ui.categoryDetailList.SetLength(len(m.categoryExpenses()))
+		m.ui.categoryDetailList.moveToBottom(m.categoryDetailMaxRows())
+		return m, nil
+	case "h", "left":
+		m.moveDetailCategory(-1)
+		return m, nil
+	case "l", "right":
+		m.moveDetailCategory(1)
+		return m, nil
+	case "enter":
+		e, ok := m.selectedCategoryExpense()
+		if !ok {
+			return m, nil
+		}
+		m.ui.detailExpense = e
+		m.ui.detailNotice = ""
+		m.ui.selected = expenseDetailOverlay
+		m.ui.overlay = overlayExpenseDetail
+		return m, nil
+	case "d":
+		e, ok := m.selectedCategoryExpense()
+		if !ok {
+			return m, nil
+		}
+		m.ui.deleteID = e.ID
+		m.ui.selected = confirmDeleteOverlay
+		m.ui.overlay = overlayConfirmDelete
+		return m, nil
+	case "space", "esc":
+		m.closeCategoryDetail()
+		return m, nil
+	case "q":
+		return m, tea.Quit
 	}
 	return m, nil
 }
@@ -312,20 +640,17 @@ func (m model) handleCategoryOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m model) handleConfirmDeleteOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "d", "enter":
-		selectedIdx := m.ui.expensesList.SelectedRow()
-		if selectedIdx >= 0 && selectedIdx < len(m.data.expenses) {
-			expense := m.data.expenses[selectedIdx]
-			db := m.db
+		if targets := m.deleteTargets(); len(targets) > 0 {
+			ids := expenseIDs(targets)
+			store := m.store
 			return m, func() tea.Msg {
-				return expenseDeletedMsg{Err: database.DeleteExpense(db, expense.ID)}
+				return expenseDeletedMsg{IDs: ids, Err: store.DeleteExpenses(ids)}
 			}
 		}
-		m.ui.selected = expensesBox
-		m.ui.overlay = overlayNone
+		m.closeExpenseOverlay()
 		return m, nil
 	case "esc":
-		m.ui.selected = expensesBox
-		m.ui.overlay = overlayNone
+		m.closeExpenseOverlay()
 		m.ui.err = nil
 		return m, nil
 	}
@@ -335,9 +660,46 @@ func (m model) handleConfirmDeleteOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cm
 // handleHelpOverlayKeys handles keys for the help overlay.
 func (m model) handleHelpOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
-	case "esc", "?":
+	case "j", "down":
+		m.ui.helpList.scroll(1, m.helpMaxRows())
+		return m, nil
+	case "k", "up":
+		m.ui.helpList.scroll(-1, m.helpMaxRows())
+		return m, nil
+	case "esc", "?", "f1":
+		m.closeHelp()
+		return m, nil
+	case "q":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// handleProfileOverlayKeys handles keys for the profile switcher overlay.
+func (m model) handleProfileOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "j", "down":
+		m.ui.profileList.moveDown(len(m.ui.profiles))
+		return m, nil
+	case "k", "up":
+		m.ui.profileList.moveUp()
+		return m, nil
+	case "enter":
+		selectedIdx := m.ui.profileList.SelectedRow()
+		if selectedIdx < 0 || selectedIdx >= len(m.ui.profiles) {
+			return m, nil
+		}
+		name := m.ui.profiles[selectedIdx]
+		if name == m.profile {
+			m.ui.selected = m.ui.previousSelected
+			m.ui.overlay = overlayNone
+			return m, nil
+		}
+		return m, switchProfile(name)
+	case "esc", "p":
 		m.ui.selected = m.ui.previousSelected
 		m.ui.overlay = overlayNone
+		m.ui.err = nil
 		return m, nil
 	case "q":
 		return m, tea.Quit
@@ -345,19 +707,98 @@ func (m model) handleHelpOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleExpenseDetailOverlayKeys handles keys for the expense detail overlay.
+func (m model) handleExpenseDetailOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	e := m.ui.detailExpense
+	switch msg.String() {
+	case "d":
+		m.ui.deleteID = e.ID
+		m.ui.detailNotice = ""
+		m.ui.selected = confirmDeleteOverlay
+		m.ui.overlay = overlayConfirmDelete
+		return m, nil
+	case "c":
+		// The copy is dated today, as D does
+		store := m.store
+		return m, func() tea.Msg {
+			id, err := store.CreateExpense(time.Now(), e.Amount, e.Description, e.Type)
+			return expenseDuplicatedMsg{ID: id, Err: err}
+		}
+	case "y":
+		m.ui.detailNotice = fmt.Sprintf("Copied ID %d to clipboard", e.ID)
+		return m, tea.SetClipboard(strconv.FormatInt(e.ID, 10))
+	case "esc", "enter":
+		m.closeExpenseOverlay()
+		m.ui.detailNotice = ""
+		m.ui.err = nil
+		return m, nil
+	case "q":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// handleGoToMonthOverlayKeys handles keys for the "go to month" prompt.
+func (m model) handleGoToMonthOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		month, err := expense.ParseMonth(m.ui.monthInput.Value())
+		if err != nil {
+			m.ui.err = err
+			return m, nil
+		}
+		m.ui.err = nil
+		m.ui.selected = m.ui.previousSelected
+		m.ui.overlay = overlayNone
+		m.ui.monthInput.Blur()
+		return m.setActiveMonth(month)
+	case "esc":
+		m.ui.selected = m.ui.previousSelected
+		m.ui.overlay = overlayNone
+		m.ui.err = nil
+		m.ui.monthInput.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.ui.monthInput, cmd = m.ui.monthInput.Update(msg)
+	return m, cmd
+}
+
+// handleQuickAddOverlayKeys handles keys for the quick add prompt. Enter does nothing
+// while the entry doesn't parse; the preview says why.
+func (m model) handleQuickAddOverlayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		if _, err := expense.ParseQuickEntry(m.ui.quickInput.Value()); err != nil {
+			return m, nil
+		}
+		return m, m.submitQuickAdd()
+	case "esc":
+		m.closeQuickAdd()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.ui.quickInput, cmd = m.ui.quickInput.Update(msg)
+	m.ui.err = nil
+	return m, cmd
+}
+
 // calculateMaxVisibleRows returns the max number of visible rows in the currently selected box
 func (m model) calculateMaxVisibleRows() int {
-	boxHeight := (m.ui.height - titleHeightForRows) / boxHeightDivisor
+	boxHeight := m.rowsBoxHeight()
 
 	switch m.ui.selected {
 	case expensesBox:
-		// Expenses box: boxHeight - borders - header - separator
-		return boxHeight - expensesBoxRowOffset
+		// Expenses box: boxHeight - borders - header - separator (- filter bar)
+		return m.expensesMaxVisibleRows()
 	case addBox:
 		// Add box doesn't have rows to navigate
 		return 1
 	case summaryBox:
-		// Summary box: boxHeight - borders - header - separator - separator - total
+		// Summary box: boxHeight - borders - header - separator - separator - total (- stacked bar)
+		if m.ui.summaryStackedBar {
+			return boxHeight - summaryBoxRowOffset - 1
+		}
 		return boxHeight - summaryBoxRowOffset
 	case monthlyReportBox:
 		// Monthly report box: boxHeight - borders - header - separator
@@ -366,7 +807,25 @@ func (m model) calculateMaxVisibleRows() int {
 	return 1
 }
 
-// reloadAllData reloads all data for the current month and monthly report
+// rowsBoxHeight returns the box height the visible rows are calculated from: the
+// boxes' share of the terminal, or in the compact layout the single box shown
+func (m model) rowsBoxHeight() int {
+	if m.isCompact() {
+		return m.ui.height - compactTabBarHeight - statusBarHeight
+	}
+	return (m.ui.height - titleHeightForRows) / boxHeightDivisor
+}
+
+// expensesMaxVisibleRows returns the max number of visible rows in the expenses box, selected or not
+func (m model) expensesMaxVisibleRows() int {
+	rows := m.rowsBoxHeight() - expensesBoxRowOffset
+	if m.showFilterBar() {
+		rows--
+	}
+	return rows
+}
+
+// reloadAllData reloads all data for the current month, the monthly report and the description history
 func (m model) reloadAllData() tea.Cmd {
-	return tea.Batch(loadMonthData(m.db, m.ui.activeMonth), loadMonthlyReportData(m.db))
+	return tea.Batch(loadMonthData(m.store, m.ui.activeMonth), loadMonthlyReportData(m.store), loadDescriptionHistory(m.store))
 }
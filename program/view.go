@@ -2,10 +2,15 @@ package program
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	lipgloss "charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kyawphyothu/sana/expense"
 	"github.com/kyawphyothu/sana/types"
 )
 
@@ -76,8 +81,8 @@ func (m model) View() tea.View {
 		return res
 	}
 
-	// Check if terminal is too small
-	if m.ui.width < minWidth || m.ui.height < minHeight {
+	// Check if terminal is too small, even for the compact layout
+	if m.ui.width < compactMinWidth || m.ui.height < compactMinHeight {
 		res := tea.NewView(m.renderTooSmallMessage())
 		res.AltScreen = true
 		return res
@@ -86,36 +91,54 @@ func (m model) View() tea.View {
 	// Build the three sections
 	titleBox := m.renderTitleBox()
 
-	if m.ui.selected == addBox {
+	if m.isLocked() {
 		res := tea.NewView(lipgloss.JoinVertical(
 			lipgloss.Left,
 			titleBox,
-			m.renderAddBox(),
+			m.renderUnlockBox(),
 		))
 		res.AltScreen = true
 		res.Cursor = m.fixedCursor()
 		return res
 	}
 
-	expensesBox := m.renderExpensesBox()
+	var mainContent string
+	if m.inAddForm() {
+		mainContent = lipgloss.JoinVertical(lipgloss.Left, titleBox, m.renderAddBox())
+	} else if m.isCompact() {
+		// One box at a time; the tab bar shows which
+		var box string
+		switch m.compactBox() {
+		case summaryBox:
+			box = m.renderSummaryBox()
+		case monthlyReportBox:
+			box = m.renderMonthlyReportBox()
+		default:
+			box = m.renderExpensesBox()
+		}
+		mainContent = lipgloss.JoinVertical(lipgloss.Left, titleBox, box)
+	} else {
+		expensesBox := m.renderExpensesBox()
 
-	summaryBox := m.renderSummaryBox()
+		summaryBox := m.renderSummaryBox()
 
-	monthlyReportBox := m.renderMonthlyReportBox()
+		monthlyReportBox := m.renderMonthlyReportBox()
 
-	summaryAndMonthlyReportBox := lipgloss.JoinHorizontal(
-		lipgloss.Left,
-		summaryBox,
-		monthlyReportBox,
-	)
+		summaryAndMonthlyReportBox := lipgloss.JoinHorizontal(
+			lipgloss.Left,
+			summaryBox,
+			monthlyReportBox,
+		)
 
-	// Stack vertically
-	mainContent := lipgloss.JoinVertical(
-		lipgloss.Left,
-		titleBox,
-		expensesBox,
-		summaryAndMonthlyReportBox,
-	)
+		// Stack vertically
+		mainContent = lipgloss.JoinVertical(
+			lipgloss.Left,
+			titleBox,
+			expensesBox,
+			summaryAndMonthlyReportBox,
+		)
+	}
+	mainContent = lipgloss.JoinVertical(lipgloss.Left, mainContent, m.renderStatusBar())
 
 	// If overlay is visible, layer it on top of main content using Canvas
 	if m.ui.overlay != overlayNone {
@@ -130,27 +153,11 @@ func (m model) View() tea.View {
 			Z(0) // Background layer
 
 		// Calculate overlay position (centered)
-		overlayHeight := len(strings.Split(overlay, "\n"))
-		overlayWidth := 0
-		for _, line := range strings.Split(overlay, "\n") {
-			width := lipgloss.Width(line)
-			if width > overlayWidth {
-				overlayWidth = width
-			}
-		}
-
-		overlayX := (m.ui.width - overlayWidth) / 2
-		overlayY := (m.ui.height - overlayHeight) / 2
-		if overlayX < 0 {
-			overlayX = 0
-		}
-		if overlayY < 0 {
-			overlayY = 0
-		}
+		position := m.overlayRect(overlay)
 
 		overlayLayer := lipgloss.NewLayer(overlay).
-			X(overlayX).
-			Y(overlayY).
+			X(position.x).
+			Y(position.y).
 			Z(1) // Foreground layer (on top)
 
 		canvas := lipgloss.NewCanvas(mainLayer, overlayLayer)
@@ -158,32 +165,91 @@ func (m model) View() tea.View {
 
 		res := tea.NewView(finalContent)
 		res.AltScreen = true
+		res.MouseMode = tea.MouseModeCellMotion
 		return res
 	}
 
 	// Stack vertically and return directly (no wrapper needed)
 	res := tea.NewView(mainContent)
 	res.AltScreen = true
+	if m.ui.selected != addBox {
+		// The add form is keyboard only, which leaves the terminal's text selection working
+		res.MouseMode = tea.MouseModeCellMotion
+	}
 	res.Cursor = m.fixedCursor()
 	return res
 }
 
-// renderTitleBox creates the top title section with Sana figlet
+// renderTitleBox creates the top title section with Sana figlet, or the tab bar in the compact layout
 func (m model) renderTitleBox() string {
+	if m.isCompact() {
+		return m.renderTabBar()
+	}
 	content := m.styles.Title.Render(sanaFiglet)
 
 	return m.styles.DrawBorder(content, BorderOptions{
 		Width:       m.ui.width,
 		Height:      titleBoxHeight,
-		Title:       "Sana",
+		Title:       m.formatTitleBoxTitle(),
 		BorderChars: DoubleBorderChars(),
 		Color:       m.styles.Theme.Border,
 	})
 }
 
+// renderTabBar renders the compact layout's header line: a tab per box, then the
+// active month (and the profile, when there is room)
+func (m model) renderTabBar() string {
+	current := m.compactBox()
+	var bar strings.Builder
+	for _, tab := range compactTabs {
+		style := m.styles.Muted
+		if tab.box == current || (tab.box == expensesBox && current == addBox) {
+			style = m.styles.Selected
+		}
+		if tab.box == expensesBox && current == addBox {
+			tab.label = "Add"
+		}
+		bar.WriteString(style.Render(" " + tab.label + " "))
+	}
+
+	info := m.ui.activeMonth.Format("Jan 2006")
+	if withProfile := m.profile + " · " + info; m.profile != "" && lipgloss.Width(bar.String())+lipgloss.Width(withProfile)+2 <= m.ui.width {
+		info = withProfile
+	}
+	gap := max(m.ui.width-lipgloss.Width(bar.String())-lipgloss.Width(info)-1, 1)
+	return bar.String() + m.styles.Line.Render(strings.Repeat(" ", gap)) + m.styles.Header.Render(info) + m.styles.Line.Render(" ")
+}
+
+// renderAmountPreview renders what the amount field comes to while it holds a
+// calculation, or the error in it; nothing for a plain number or an empty field.
+func (m model) renderAmountPreview() string {
+	value := m.form.amount.Value()
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	amount, err := expense.EvalAmount(value)
+	if err != nil {
+		return m.styles.Line.Foreground(m.styles.Theme.Error).Render("✗ " + err.Error())
+	}
+	if !expense.IsAmountExpression(value) {
+		return ""
+	}
+	return m.styles.Muted.Render("= " + formatAmountWithCommas(amount))
+}
+
 // renderAddBox creates the add expense form section (middle box)
 func (m model) renderAddBox() string {
 	boxHeight := m.calculateAddBoxHeight()
+	amountPreview := m.renderAmountPreview()
+
+	if m.isCompact() {
+		// Fit the inputs into the narrow box (m is a copy, so this only affects this render)
+		inputWidth := max(m.ui.width-innerWidthPadding-promptWidth-2, 1)
+		m.form.typeField.SetWidth(inputWidth)
+		m.form.amount.SetWidth(max(inputWidth-lipgloss.Width(amountPreview)-1, 1))
+		m.form.description.SetWidth(inputWidth)
+		m.form.date.SetWidth(inputWidth)
+	}
 
 	// Update prompt styles based on focus before rendering
 	m.updatePromptStyles()
@@ -193,20 +259,32 @@ func (m model) renderAddBox() string {
 	// Form rows (each textinput has its own prompt)
 	rows := []string{
 		m.form.typeField.View(),
-		m.form.amount.View(),
+		m.form.amount.View() + " " + amountPreview,
 		m.form.description.View(),
 		m.form.date.View(),
 	}
-	formContent := strings.Join(rows, "\n\n")
-
-	helpText := helpStyle.Render("↑/↓: move • Tab: autocomplete • Enter: submit • Esc: cancel")
+	rowSeparator := "\n\n"
+	if m.isCompact() {
+		rowSeparator = "\n"
+	}
+	formContent := strings.Join(rows, rowSeparator)
 
 	// List available expense types
 	typeLabels := types.ExpenseTypeSuggestions()
 	typesList := "Available types: " + strings.Join(typeLabels, ", ")
+	// While a known description is being entered, show what picking it fills in
+	if m.form.focused == addFormDescription {
+		desc := m.form.description.Value()
+		if suggestion := m.form.description.CurrentSuggestion(); desc != "" && suggestion != "" {
+			desc = suggestion
+		}
+		if h, ok := expense.FindDescription(m.data.descriptionHistory, desc); ok {
+			typesList = fmt.Sprintf("%s: usually %s, last %s (tab to pick)", h.Description, h.Type.String(), formatAmountWithCommas(h.Amount))
+		}
+	}
 	typesText := helpStyle.Render(typesList)
 
-	content := formContent + "\n\n" + helpText + "\n" + typesText
+	content := formContent + "\n\n" + typesText
 
 	// Show validation/creation error below form if any
 	if m.ui.err != nil && m.ui.selected == addBox {
@@ -232,11 +310,37 @@ func (m model) renderAddBox() string {
 	})
 }
 
+// renderUnlockBox renders the passphrase prompt for a locked (encrypted) profile.
+func (m model) renderUnlockBox() string {
+	content := m.styles.Line.Render(fmt.Sprintf("Profile %q is encrypted.", m.unlock.profile)) +
+		"\n\n" + m.unlock.passphrase.View() + "\n\n"
+	switch {
+	case m.unlock.unlocking:
+		content += m.styles.Muted.Render("Unlocking...")
+	case m.ui.err != nil:
+		content += m.styles.Line.Foreground(m.styles.Theme.Error).Render("Error: " + m.ui.err.Error())
+	default:
+		esc := "Esc: quit"
+		if m.store != nil {
+			esc = fmt.Sprintf("Esc: back to %q", m.profile)
+		}
+		content += m.styles.Muted.Render("Enter: unlock • " + esc)
+	}
+
+	return m.styles.DrawBorder(content, BorderOptions{
+		Width:       m.ui.width,
+		Height:      m.ui.height - m.titleHeight(),
+		Title:       m.styles.Base.Foreground(m.styles.Theme.Selected).Bold(true).Render("Unlock"),
+		BorderChars: RoundedBorderChars(),
+		Color:       m.styles.Theme.Primary,
+	})
+}
+
 // renderTooSmallMessage creates small terminal message when terminal is too small
 func (m model) renderTooSmallMessage() string {
 	message := fmt.Sprintf(
 		"Terminal too small!\n\nMinimum size: %dx%d\nCurrent size: %dx%d\n\nPlease resize your terminal.",
-		minWidth, minHeight, m.ui.width, m.ui.height,
+		compactMinWidth, compactMinHeight, m.ui.width, m.ui.height,
 	)
 	return m.styles.Parent.
 		Width(m.ui.width).
@@ -283,21 +387,31 @@ func (m model) buildOverlayTableHeader(tableWidth int, widths overlayColumnWidth
 	return m.styles.Header.Render(header) + "\n" + m.styles.Muted.Render(separator) + "\n"
 }
 
-// renderOverlayExpenseRow renders a single expense row in the overlay (no selection highlight)
-func (m model) renderOverlayExpenseRow(expense types.Expense, widths overlayColumnWidths) string {
+// renderOverlayExpenseRow renders a single expense row in the overlay with optional selection highlight
+func (m model) renderOverlayExpenseRow(expense types.Expense, widths overlayColumnWidths, isSelected bool) string {
 	desc := expense.Description
 	if len(desc) > widths.Description {
 		desc = desc[:widths.Description-descTruncateSuffix] + "..."
 	}
 
+	style := m.styles.Line
+	if isSelected {
+		style = lipgloss.NewStyle().Foreground(lipgloss.Color("#0F1117")).Background(m.styles.Theme.Primary).Bold(true)
+	}
 	formattedAmount := formatAmountWithCommas(expense.Amount)
-	datePart := m.styles.Line.Width(widths.Date).Align(lipgloss.Left).Render(expense.Date.Format("2006-01-02"))
-	descriptionPart := m.styles.Line.Width(widths.Description).Align(lipgloss.Left).Render(desc)
-	amountPart := m.styles.Line.Width(widths.Amount).Align(lipgloss.Right).Render(formattedAmount)
-	spacingStr := m.styles.Line.Render("  ")
+	datePart := style.Width(widths.Date).Align(lipgloss.Left).Render(expense.Date.Format("2006-01-02"))
+	descriptionPart := style.Width(widths.Description).Align(lipgloss.Left).Render(desc)
+	amountPart := style.Width(widths.Amount).Align(lipgloss.Right).Render(formattedAmount)
+	spacingStr := style.Render("  ")
 	return datePart + spacingStr + descriptionPart + spacingStr + amountPart
 }
 
+// fitWidth narrows an overlay's width to the terminal, which only matters in the
+// compact layout; content that doesn't fit is cut off by DrawBorder.
+func (m model) fitWidth(width int) int {
+	return min(width, m.ui.width)
+}
+
 // renderOverlay dispatches to the appropriate overlay renderer based on the active overlay kind.
 func (m model) renderOverlay() string {
 	switch m.ui.overlay {
@@ -307,28 +421,41 @@ func (m model) renderOverlay() string {
 		return m.renderConfirmDeleteOverlay()
 	case overlayHelp:
 		return m.renderHelpOverlay()
+	case overlayProfiles:
+		return m.renderProfileOverlay()
+	case overlayGoToMonth:
+		return m.renderGoToMonthOverlay()
+	case overlayExpenseDetail:
+		return m.renderExpenseDetailOverlay()
+	case overlayRecategorize:
+		return m.renderRecategorizeOverlay()
+	case overlayCalendar:
+		return m.renderCalendarOverlay()
+	case overlayQuickAdd:
+		return m.renderQuickAddOverlay()
 	}
 	return ""
 }
 
-// renderCategoryDetailOverlay renders the overlay showing expenses for the selected category.
+// renderCategoryDetailOverlay renders the overlay listing the expenses of the detail
+// category, with its subtotal, count and average above them.
 func (m model) renderCategoryDetailOverlay() string {
-	if m.ui.summaryList.SelectedRow() < 0 || m.ui.summaryList.SelectedRow() >= len(m.data.summary) {
-		return m.styles.Muted.Render("No category selected")
-	}
-
-	selectedCategory := m.data.summary[m.ui.summaryList.SelectedRow()].Category
-	filteredExpenses := m.filterExpensesByCategory(selectedCategory)
+	category := m.ui.detailCategory
+	expenses := m.categoryExpenses()
 
-	categoryColor := CategoryColor(selectedCategory)
+	categoryColor := CategoryColor(category)
 	categoryStyle := lipgloss.NewStyle().Foreground(categoryColor).Bold(true).Background(m.styles.Theme.Background)
+	title := categoryStyle.Render(category)
+	if i := slices.IndexFunc(m.data.summary, func(s types.CategorySummary) bool { return s.Category == category }); i >= 0 {
+		title += m.styles.Muted.Render(fmt.Sprintf(" %d/%d", i+1, len(m.data.summary)))
+	}
 
-	if len(filteredExpenses) == 0 {
-		content := fmt.Sprintf("No expenses found for category: %s", selectedCategory)
+	if len(expenses) == 0 {
+		content := fmt.Sprintf("No expenses found for category: %s", category)
 		return m.styles.DrawBorder(content, BorderOptions{
-			Width:       overlayMinWidth,
+			Width:       m.fitWidth(overlayMinWidth),
 			Height:      overlayMinHeight,
-			Title:       categoryStyle.Render(selectedCategory),
+			Title:       title,
 			BorderChars: RoundedBorderChars(),
 			Color:       m.styles.Theme.Primary,
 		})
@@ -341,29 +468,43 @@ func (m model) renderCategoryDetailOverlay() string {
 	if overlayWidth > overlayMaxWidth {
 		overlayWidth = overlayMaxWidth
 	}
+	overlayWidth = m.fitWidth(overlayWidth)
 	tableWidth := overlayWidth - tableBorderPadding
 	widths := m.calculateOverlayColumnWidths(tableWidth)
 
 	var content strings.Builder
-	content.WriteString(m.buildOverlayTableHeader(tableWidth, widths))
-
-	maxRows := overlayMaxRows
-	if len(filteredExpenses) < maxRows {
-		maxRows = len(filteredExpenses)
+	subtotal, count, average := categoryStats(expenses)
+	stats := fmt.Sprintf("Subtotal %s • %d expense", formatAmountWithCommas(subtotal), count)
+	if count != 1 {
+		stats += "s"
 	}
-	for i := 0; i < maxRows; i++ {
-		content.WriteString(m.renderOverlayExpenseRow(filteredExpenses[i], widths) + "\n")
+	stats += " • Average " + formatAmountWithCommas(average)
+	content.WriteString(m.styles.Header.Render(stats))
+	content.WriteString("\n")
+
+	maxRows := min(m.categoryDetailMaxRows(), len(expenses))
+	config := TableConfig{
+		TableWidth:       tableWidth,
+		Header:           m.buildOverlayTableHeader(tableWidth, widths),
+		MaxRows:          maxRows,
+		TotalRows:        len(expenses),
+		ScrollOffset:     m.ui.categoryDetailList.ScrollOffset(),
+		SelectedRowIndex: m.ui.categoryDetailList.SelectedRow(),
+		HasFocus:         true,
 	}
+	content.WriteString(m.renderTableBody(config, func(i int, isSelected bool) string {
+		return m.renderOverlayExpenseRow(expenses[i], widths, isSelected)
+	}))
 
-	if len(filteredExpenses) > maxRows {
-		remaining := len(filteredExpenses) - maxRows
+	overlayHeight := maxRows + overlayHeaderRows + 1 // + stats line
+	if m.ui.err != nil {
+		content.WriteString(m.styles.Line.Foreground(m.styles.Theme.Error).Render("Error: " + m.ui.err.Error()))
 		content.WriteString("\n")
-		content.WriteString(m.styles.Muted.Render(fmt.Sprintf("... and %d more", remaining)))
+		overlayHeight++
 	}
 	content.WriteString("\n")
-	content.WriteString(m.styles.Muted.Render("Press Space or Esc to close"))
+	content.WriteString(m.renderOverlayButtons())
 
-	overlayHeight := maxRows + overlayHeaderRows
 	if overlayHeight < overlayMinHeightFallback {
 		overlayHeight = overlayMinHeightFallback
 	}
@@ -371,7 +512,7 @@ func (m model) renderCategoryDetailOverlay() string {
 	return m.styles.DrawBorder(content.String(), BorderOptions{
 		Width:       overlayWidth,
 		Height:      overlayHeight,
-		Title:       categoryStyle.Render(selectedCategory),
+		Title:       title,
 		BorderChars: RoundedBorderChars(),
 		Color:       m.styles.Theme.Primary,
 	})
@@ -379,12 +520,15 @@ func (m model) renderCategoryDetailOverlay() string {
 
 // renderConfirmDeleteOverlay renders the confirmation overlay for deleting an expense.
 func (m model) renderConfirmDeleteOverlay() string {
-	selectedIdx := m.ui.expensesList.SelectedRow()
-	if selectedIdx < 0 || selectedIdx >= len(m.data.expenses) {
+	targets := m.deleteTargets()
+	if len(targets) == 0 {
 		return m.styles.Muted.Render("No expense selected")
 	}
+	if len(targets) > 1 {
+		return m.renderConfirmBulkDeleteOverlay(targets)
+	}
 
-	expense := m.data.expenses[selectedIdx]
+	expense := targets[0]
 	formattedAmount := formatAmountWithCommas(expense.Amount)
 
 	var content strings.Builder
@@ -413,10 +557,10 @@ func (m model) renderConfirmDeleteOverlay() string {
 		overlayHeight += 1
 	}
 
-	content.WriteString(m.styles.Muted.Render("d/Enter: delete • Esc: cancel"))
+	content.WriteString(m.renderOverlayButtons())
 
 	return m.styles.DrawBorder(content.String(), BorderOptions{
-		Width:       confirmDeleteOverlayWidth,
+		Width:       m.fitWidth(confirmDeleteOverlayWidth),
 		Height:      overlayHeight,
 		Title:       "Confirm Delete",
 		BorderChars: RoundedBorderChars(),
@@ -424,70 +568,340 @@ func (m model) renderConfirmDeleteOverlay() string {
 	})
 }
 
-// renderHelpOverlay renders the help overlay for the expenses box.
-func (m model) renderHelpOverlay() string {
-	lengthOfKey := 8
+// renderConfirmBulkDeleteOverlay asks to delete several marked expenses at once.
+func (m model) renderConfirmBulkDeleteOverlay(targets []types.Expense) string {
+	var total float64
+	for _, e := range targets {
+		total += e.Amount
+	}
 	var content strings.Builder
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("? " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Help Menu"))
+	content.WriteString(m.styles.Line.Render(fmt.Sprintf("Expenses: %d", len(targets))))
 	content.WriteString("\n")
+	content.WriteString(m.styles.Line.Render(fmt.Sprintf("Total:    %s", formatAmountWithCommas(total))))
+	content.WriteString("\n\n")
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("q " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Quit"))
-	content.WriteString("\n")
+	warningStyle := m.styles.Line.Foreground(m.styles.Theme.Error).Bold(true)
+	content.WriteString(warningStyle.Render(fmt.Sprintf("Delete these %d expenses?", len(targets))))
+	content.WriteString("\n\n")
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("r " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Reload"))
-	content.WriteString("\n")
+	overlayHeight := confirmDeleteOverlayHeight - 2
+	if m.ui.err != nil {
+		content.WriteString(m.styles.Line.Foreground(m.styles.Theme.Error).Render("Error: " + m.ui.err.Error()))
+		content.WriteString("\n")
+		overlayHeight++
+	}
+	content.WriteString(m.renderOverlayButtons())
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("a " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Add Expense"))
-	content.WriteString("\n")
+	return m.styles.DrawBorder(content.String(), BorderOptions{
+		Width:       m.fitWidth(confirmDeleteOverlayWidth),
+		Height:      overlayHeight,
+		Title:       "Confirm Delete",
+		BorderChars: RoundedBorderChars(),
+		Color:       m.styles.Theme.Error,
+	})
+}
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("e " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Expense"))
-	content.WriteString("\n")
+// renderRecategorizeOverlay renders the category picker for the marked (or selected) expenses.
+func (m model) renderRecategorizeOverlay() string {
+	var content strings.Builder
+	for i, t := range types.AllExpenseTypes() {
+		line := fmt.Sprintf("  %-*s", profileOverlayWidth-tableBorderPadding-2, t.String())
+		if i == m.ui.categoryList.SelectedRow() {
+			content.WriteString(m.styles.Selected.Render(line))
+		} else {
+			content.WriteString(m.styles.Line.Foreground(CategoryColor(t.String())).Render(line))
+		}
+		content.WriteString("\n")
+	}
+	overlayHeight := len(types.AllExpenseTypes()) + profileOverlayExtraRows
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("d " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Delete Expense"))
+	if m.ui.err != nil {
+		content.WriteString("\n")
+		content.WriteString(m.styles.Line.Foreground(m.styles.Theme.Error).Render("Error: " + m.ui.err.Error()))
+		overlayHeight += 2
+	}
 	content.WriteString("\n")
+	content.WriteString(m.renderOverlayButtons())
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("s " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Summary"))
-	content.WriteString("\n")
+	title := fmt.Sprintf("Category for %d expense", len(m.bulkTargets()))
+	if len(m.bulkTargets()) != 1 {
+		title += "s"
+	}
+	return m.styles.DrawBorder(content.String(), BorderOptions{
+		Width:       m.fitWidth(profileOverlayWidth),
+		Height:      overlayHeight,
+		Title:       m.styles.Base.Foreground(m.styles.Theme.Selected).Bold(true).Render(title),
+		BorderChars: RoundedBorderChars(),
+		Color:       m.styles.Theme.Primary,
+	})
+}
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("<space> " + strings.Repeat(" ", lengthOfKey-7)))
-	content.WriteString(m.styles.Muted.Render("Category Detail"))
-	content.WriteString("\n")
+// renderCalendarOverlay renders the active month as a heatmap of daily spending,
+// with the selected day's total below it.
+func (m model) renderCalendarOverlay() string {
+	month := m.ui.activeMonth
+	days := daysInMonth(month)
+	byDay, highest := dailyTotalsByDay(m.data.daily)
+
+	grid := make([][]string, 7)
+	for row := range grid {
+		grid[row] = make([]string, calendarWeeks(month))
+		for col := range grid[row] {
+			grid[row][col] = m.styles.Line.Render("  ")
+		}
+	}
+	for day := 1; day <= days; day++ {
+		level := heatLevel(byDay[day].Total, highest)
+		style := m.styles.Base.Background(HeatmapColor(level))
+		switch {
+		case day == m.ui.calendarDay:
+			style = m.styles.Selected
+		case level == 0:
+			style = style.Foreground(m.styles.Theme.Muted)
+		case level >= 3:
+			style = style.Foreground(m.styles.Theme.Background)
+		}
+		row, col := calendarCell(month, day)
+		grid[row][col] = style.Render(fmt.Sprintf("%2d", day))
+	}
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("m " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Monthly Report"))
+	var content strings.Builder
+	for row, cells := range grid {
+		weekday := time.Weekday((row + 1) % 7).String()[:3]
+		content.WriteString(m.styles.Muted.Render(fmt.Sprintf("%-*s", calendarLabelWidth, weekday)))
+		content.WriteString(strings.Join(cells, m.styles.Line.Render(" ")))
+		content.WriteString("\n")
+	}
 	content.WriteString("\n")
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("<enter> " + strings.Repeat(" ", lengthOfKey-7)))
-	content.WriteString(m.styles.Muted.Render("Select Month"))
+	selected := time.Date(month.Year(), month.Month(), max(m.ui.calendarDay, 1), 0, 0, 0, 0, month.Location())
+	content.WriteString(m.styles.Header.Render(selected.Format("Mon, 2 Jan") + ": "))
+	switch d := byDay[m.ui.calendarDay]; d.Count {
+	case 0:
+		content.WriteString(m.styles.Muted.Render("no expenses"))
+	case 1:
+		content.WriteString(m.styles.Line.Render("1 expense • " + formatAmountWithCommas(d.Total)))
+	default:
+		content.WriteString(m.styles.Line.Render(fmt.Sprintf("%d expenses • %s", d.Count, formatAmountWithCommas(d.Total))))
+	}
 	content.WriteString("\n")
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("j " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Move Down"))
+	content.WriteString(m.styles.Muted.Render("Less "))
+	for level := range numHeatLevels {
+		content.WriteString(m.styles.Base.Background(HeatmapColor(level)).Render("  "))
+		content.WriteString(m.styles.Line.Render(" "))
+	}
+	content.WriteString(m.styles.Muted.Render("More"))
 	content.WriteString("\n")
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("k " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Move Up"))
+	if m.ui.err != nil {
+		content.WriteString("\n")
+		content.WriteString(m.styles.Line.Foreground(m.styles.Theme.Error).Render("Error: " + m.ui.err.Error()))
+		content.WriteString("\n")
+	}
 	content.WriteString("\n")
+	content.WriteString(m.renderOverlayButtons())
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("g " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Move to Top"))
-	content.WriteString("\n")
+	return m.styles.DrawBorder(content.String(), BorderOptions{
+		Width:       m.fitWidth(calendarOverlayWidth),
+		Height:      lipgloss.Height(content.String()) + innerHeightPadding,
+		Title:       m.styles.Base.Foreground(m.styles.Theme.Selected).Bold(true).Render(month.Format("January 2006")),
+		BorderChars: RoundedBorderChars(),
+		Color:       m.styles.Theme.Primary,
+	})
+}
+
+// renderHelpOverlay renders the key bindings of the box or overlay help was opened
+// from, scrolled to fit the screen.
+func (m model) renderHelpOverlay() string {
+	lines := m.helpLines(m.ui.helpScope)
+	start := m.ui.helpList.ScrollOffset()
+	end := min(start+m.helpMaxRows(), len(lines))
+
+	content := strings.Join(lines[start:end], "\n") + "\n\n" + m.renderOverlayButtons()
+
+	return m.styles.DrawBorder(content, BorderOptions{
+		Width:       m.fitWidth(overlayMinWidth),
+		Height:      lipgloss.Height(content) + innerHeightPadding,
+		Title:       m.styles.Base.Foreground(m.styles.Theme.Selected).Bold(true).Render("Help · " + keyScopeNames[m.ui.helpScope]),
+		BorderChars: RoundedBorderChars(),
+		Color:       m.styles.Theme.Primary,
+	})
+}
+
+// renderExpenseDetailOverlay renders every field of the detail expense, with the description wrapped.
+func (m model) renderExpenseDetailOverlay() string {
+	e := m.ui.detailExpense
+	valueWidth := expenseDetailOverlayWidth - innerWidthPadding - expenseDetailLabelWidth
+	labelStyle := m.styles.Muted.Width(expenseDetailLabelWidth)
+	valueStyle := m.styles.Line.Width(valueWidth)
+
+	desc := e.Description
+	if desc == "" {
+		desc = "-"
+	}
+	fields := []struct{ label, value string }{
+		{"ID", strconv.FormatInt(e.ID, 10)},
+		{"UUID", e.UUID},
+		{"Date", e.Date.Format("2006-01-02 15:04:05")},
+		{"Category", e.Type.String()},
+		{"Amount", formatAmountWithCommas(e.Amount)},
+		{"Description", desc},
+		{"Created", formatDetailTime(e.CreatedAt)},
+		{"Updated", formatDetailTime(e.UpdatedAt)},
+	}
+	rows := make([]string, 0, len(fields))
+	for _, f := range fields {
+		// Width wraps long values; the label lines up with the first line
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render(f.label+":"), valueStyle.Render(f.value)))
+	}
+	content := strings.Join(rows, "\n") + "\n\n"
+
+	switch {
+	case m.ui.err != nil:
+		content += m.styles.Line.Foreground(m.styles.Theme.Error).Render("Error: "+m.ui.err.Error()) + "\n"
+	case m.ui.detailNotice != "":
+		content += m.styles.Line.Foreground(m.styles.Theme.Success).Render(m.ui.detailNotice) + "\n"
+	}
+	content += m.renderOverlayButtons()
+
+	return m.styles.DrawBorder(content, BorderOptions{
+		Width:       m.fitWidth(expenseDetailOverlayWidth),
+		Height:      lipgloss.Height(content) + innerHeightPadding,
+		Title:       m.styles.Base.Foreground(m.styles.Theme.Selected).Bold(true).Render("Expense"),
+		BorderChars: RoundedBorderChars(),
+		Color:       m.styles.Theme.Primary,
+	})
+}
 
-	content.WriteString(m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("G " + strings.Repeat(" ", lengthOfKey-1)))
-	content.WriteString(m.styles.Muted.Render("Move to Bottom"))
+// formatDetailTime formats a created/updated timestamp in local time, or "-" if unknown.
+func formatDetailTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// renderGoToMonthOverlay renders the "go to month" prompt.
+func (m model) renderGoToMonthOverlay() string {
+	content := m.ui.monthInput.View() + "\n\n"
+	overlayHeight := goToMonthOverlayHeight
+	if m.ui.err != nil {
+		content += m.styles.Line.Foreground(m.styles.Theme.Error).Render("Error: "+m.ui.err.Error()) + "\n"
+		overlayHeight++
+	}
+	content += m.renderOverlayButtons()
+
+	return m.styles.DrawBorder(content, BorderOptions{
+		Width:       m.fitWidth(goToMonthOverlayWidth),
+		Height:      overlayHeight,
+		Title:       m.styles.Base.Foreground(m.styles.Theme.Selected).Bold(true).Render("Go to Month"),
+		BorderChars: RoundedBorderChars(),
+		Color:       m.styles.Theme.Primary,
+	})
+}
+
+// renderQuickAddOverlay renders the quick add prompt with how the entry is understood
+// so far: its fields, or why it can't be added.
+func (m model) renderQuickAddOverlay() string {
+	content := m.ui.quickInput.View() + "\n\n" + m.renderQuickAddPreview() + "\n\n"
+	overlayHeight := quickAddOverlayHeight
+	if m.ui.err != nil {
+		content += m.styles.Line.Foreground(m.styles.Theme.Error).Render("Error: "+m.ui.err.Error()) + "\n"
+		overlayHeight++
+	}
+	content += m.renderOverlayButtons()
+
+	return m.styles.DrawBorder(content, BorderOptions{
+		Width:       m.fitWidth(quickAddOverlayWidth),
+		Height:      overlayHeight,
+		Title:       m.styles.Base.Foreground(m.styles.Theme.Selected).Bold(true).Render("Quick Add"),
+		BorderChars: RoundedBorderChars(),
+		Color:       m.styles.Theme.Primary,
+	})
+}
+
+// renderQuickAddPreview renders the parsed quick entry, quickAddPreviewLines lines high.
+func (m model) renderQuickAddPreview() string {
+	valueWidth := quickAddOverlayWidth - innerWidthPadding - expenseDetailLabelWidth
+	var lines []string
+	line := m.ui.quickInput.Value()
+	q, err := expense.ParseQuickEntry(line)
+	switch {
+	case strings.TrimSpace(line) == "":
+		lines = []string{
+			m.styles.Muted.Render("amount description [@category] [date]"),
+			m.styles.Muted.Render("e.g. 12 lunch last fri 13:00"),
+			m.styles.Muted.Render("     45.60/3 taxi @trans 3 days ago"),
+		}
+	case err != nil:
+		lines = []string{m.styles.Line.Foreground(m.styles.Theme.Error).Width(quickAddOverlayWidth - innerWidthPadding).Render("✗ " + err.Error())}
+	default:
+		t, usual := m.quickEntryType(q)
+		category := t.String()
+		if usual {
+			category += " (usual)"
+		}
+		amount := formatAmountWithCommas(q.Amount)
+		if expense.IsAmountExpression(q.AmountText) {
+			amount += " (" + q.AmountText + ")"
+		}
+		date := q.Date.Format("2006-01-02 15:04")
+		if q.DateText == "" {
+			date += " (now)"
+		}
+		desc := q.Description
+		if desc == "" {
+			desc = "-"
+		}
+		labelStyle := m.styles.Muted.Width(expenseDetailLabelWidth)
+		for _, f := range []struct{ label, value string }{
+			{"Amount", amount},
+			{"Category", category},
+			{"Description", desc},
+			{"Date", date},
+		} {
+			value := ansi.Truncate(f.value, valueWidth, "…")
+			lines = append(lines, labelStyle.Render(f.label+":")+m.styles.Line.Render(value))
+		}
+	}
+	content := strings.Join(lines, "\n")
+	for n := lipgloss.Height(content); n < quickAddPreviewLines; n++ {
+		content += "\n"
+	}
+	return content
+}
+
+// renderProfileOverlay renders the profile switcher with the active profile marked.
+func (m model) renderProfileOverlay() string {
+	var content strings.Builder
+	for i, name := range m.ui.profiles {
+		marker := "  "
+		if name == m.profile {
+			marker = "* "
+		}
+		line := fmt.Sprintf("%s%-*s", marker, profileOverlayWidth-tableBorderPadding-2, name)
+		if i == m.ui.profileList.SelectedRow() {
+			content.WriteString(m.styles.Selected.Render(line))
+		} else {
+			content.WriteString(m.styles.Line.Render(line))
+		}
+		content.WriteString("\n")
+	}
+	overlayHeight := len(m.ui.profiles) + profileOverlayExtraRows
+
+	if m.ui.err != nil {
+		content.WriteString("\n")
+		content.WriteString(m.styles.Line.Foreground(m.styles.Theme.Error).Render("Error: " + m.ui.err.Error()))
+		overlayHeight += 2
+	}
 	content.WriteString("\n")
+	content.WriteString(m.renderOverlayButtons())
 
 	return m.styles.DrawBorder(content.String(), BorderOptions{
-		Width:       overlayMinWidth,
-		Height:      15,
-		Title:       m.styles.Base.Foreground(m.styles.Theme.Selected).Bold(true).Render("Help"),
+		Width:       m.fitWidth(profileOverlayWidth),
+		Height:      overlayHeight,
+		Title:       m.styles.Base.Foreground(m.styles.Theme.Selected).Bold(true).Render("Profiles"),
 		BorderChars: RoundedBorderChars(),
 		Color:       m.styles.Theme.Primary,
 	})
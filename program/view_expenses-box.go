@@ -15,30 +15,53 @@ func (m model) renderExpensesBox() string {
 
 	var content strings.Builder
 
-	if len(m.data.expenses) == 0 {
-		content.WriteString(m.styles.Muted.Render("No expenses yet"))
+	if len(m.data.expenses) == 0 && len(m.data.allExpenses) > 0 {
+		content.WriteString(m.styles.Muted.Render("No expenses match the filter"))
+		content.WriteString("\n")
+		content.WriteString(m.styles.Muted.Render("/: edit filter • esc: clear filter"))
+	} else if len(m.data.expenses) == 0 {
+		content.WriteString(m.styles.Muted.Render(fmt.Sprintf("No expenses in %s", m.ui.activeMonth.Format("January 2006"))))
+		content.WriteString("\n")
+		content.WriteString(m.styles.Muted.Render("[/]: previous/next month • M: go to month • a: add"))
 	} else {
 		tableWidth := m.ui.width - tableBorderPadding
 		maxRows := boxHeight - expensesBoxHeaderRows
+		if m.showFilterBar() {
+			maxRows--
+		}
 		if maxRows < 1 {
 			maxRows = 1
 		}
 		widths := m.calculateExpenseColumnWidths(tableWidth)
+		rows := m.expenseRows()
 		config := TableConfig{
 			TableWidth:       tableWidth,
 			Header:           m.buildExpensesTableHeader(tableWidth),
 			MaxRows:          maxRows,
-			TotalRows:        len(m.data.expenses),
+			TotalRows:        len(rows),
 			ScrollOffset:     m.ui.expensesList.ScrollOffset(),
 			SelectedRowIndex: m.ui.expensesList.SelectedRow(),
 			HasFocus:         m.isSelected(expensesBox),
 		}
 		renderRow := func(globalRowIndex int, isSelected bool) string {
-			return m.renderExpenseRow(m.data.expenses[globalRowIndex], widths, isSelected)
+			row := rows[globalRowIndex]
+			if row.header {
+				return m.renderDayHeaderRow(row, widths, isSelected)
+			}
+			return m.renderExpenseRow(m.data.expenses[row.index], widths, isSelected, m.isMarked(row.index))
 		}
 		content.WriteString(m.renderTableBody(config, renderRow))
 	}
 
+	if m.showFilterBar() {
+		// Pin the filter bar to the last row of the box
+		if pad := boxHeight - 2 - 1 - lipgloss.Height(content.String()); pad > 0 {
+			content.WriteString(strings.Repeat("\n", pad))
+		}
+		content.WriteString("\n")
+		content.WriteString(m.renderFilterBar())
+	}
+
 	// Show error if any
 	if m.ui.err != nil {
 		content.WriteString("\n")
@@ -65,25 +88,53 @@ func (m model) renderExpensesBox() string {
 	})
 }
 
+// renderFilterBar renders the filter input while editing, otherwise the applied filter
+func (m model) renderFilterBar() string {
+	if m.ui.filtering {
+		bar := m.ui.filterInput.View()
+		if m.ui.filterErr != nil {
+			bar += "  " + m.styles.Line.Foreground(m.styles.Theme.Error).Render(m.ui.filterErr.Error())
+		}
+		return bar
+	}
+	label := m.styles.Base.Foreground(m.styles.Theme.Primary).Bold(true).Render("Filter: ")
+	return label + m.styles.Line.Render(m.ui.filterQuery) + m.styles.Muted.Render("  (/: edit • esc: clear)")
+}
+
 // buildExpensesTableHeader returns the table header line and separator for the expenses box
 func (m model) buildExpensesTableHeader(tableWidth int) string {
 	widths := m.calculateExpenseColumnWidths(tableWidth)
-	header := fmt.Sprintf("%-*s  %-*s  %-*s  %*s",
-		widths.Date, "Date",
-		widths.Desc, "Description",
-		widths.Category, "Category",
-		widths.Amount, "Amount")
+	labels := [numExpenseSortKeys]string{"Date", "Amount", "Category", "Description"}
+	indicator := " ▼"
+	if m.ui.expenseSort.asc {
+		indicator = " ▲"
+	}
+	labels[m.ui.expenseSort.key] += indicator
+	header := fmt.Sprintf("%-*s  %-*s  ", widths.Date, labels[sortByDate], widths.Desc, labels[sortByDescription])
+	if widths.Category > 0 {
+		header += fmt.Sprintf("%-*s  ", widths.Category, labels[sortByCategory])
+	}
+	header += fmt.Sprintf("%*s", widths.Amount, labels[sortByAmount])
 	separator := strings.Repeat("─", tableWidth)
 	return m.styles.Header.Render(header) + "\n" + m.styles.Muted.Render(separator) + "\n"
 }
 
-// calculateExpenseColumnWidths computes column widths for the expenses table from available table width
+// calculateExpenseColumnWidths computes column widths for the expenses table from available table width.
+// The compact layout shows dates without the time, and Category is 0 (left out) when it doesn't fit.
 func (m model) calculateExpenseColumnWidths(tableWidth int) expenseColumnWidths {
 	dateWidth := tableDateWidth
 	categoryWidth := tableCategoryWidth
 	amountWidth := tableAmountWidth
 	spacing := tableColumnSpacing
 	totalSpacing := spacing * tableColumnGapsExpenses
+	if m.isCompact() {
+		dateWidth = tableCompactDateWidth
+		amountWidth = tableCompactAmtWidth
+		if tableWidth-dateWidth-categoryWidth-amountWidth-totalSpacing < tableMinDescWidth {
+			categoryWidth = 0
+			totalSpacing -= spacing
+		}
+	}
 	descWidth := tableWidth - dateWidth - categoryWidth - amountWidth - totalSpacing
 	if descWidth < tableMinDescWidth {
 		descWidth = tableMinDescWidth
@@ -96,8 +147,38 @@ func (m model) calculateExpenseColumnWidths(tableWidth int) expenseColumnWidths
 	}
 }
 
-// renderExpenseRow renders a single expense row with optional selection highlight
-func (m model) renderExpenseRow(expense types.Expense, widths expenseColumnWidths, isSelected bool) string {
+// renderDayHeaderRow renders the header of a day in the grouped expenses list:
+// fold marker, weekday and date, number of expenses and the day's total
+func (m model) renderDayHeaderRow(row expenseRow, widths expenseColumnWidths, isSelected bool) string {
+	marker := "▾"
+	if m.ui.collapsedDays[dayKey(row.date)] {
+		marker = "▸"
+	}
+	count := fmt.Sprintf("%d expenses", row.count)
+	if row.count == 1 {
+		count = "1 expense"
+	}
+	labelWidth := widths.Date + widths.Desc + tableColumnSpacing
+	if widths.Category > 0 {
+		labelWidth += widths.Category + tableColumnSpacing
+	}
+	label := fmt.Sprintf("%s %s  %s", marker, row.date.Format("Monday, 2 Jan"), count)
+	if m.isCompact() {
+		label = fmt.Sprintf("%s %s (%d)", marker, row.date.Format("Mon 2 Jan"), row.count)
+	}
+
+	style := m.styles.Header
+	if isSelected {
+		style = m.styles.Selected
+	}
+	return style.Width(labelWidth).Render(label) +
+		style.Render(strings.Repeat(" ", tableColumnSpacing)) +
+		style.Width(widths.Amount).Align(lipgloss.Right).Render(formatAmountWithCommas(row.total))
+}
+
+// renderExpenseRow renders a single expense row with optional selection highlight;
+// marked rows are tinted and flagged after the date
+func (m model) renderExpenseRow(expense types.Expense, widths expenseColumnWidths, isSelected, isMarked bool) string {
 	desc := expense.Description
 	if len(desc) > widths.Desc {
 		desc = desc[:widths.Desc-descTruncateSuffix] + "..."
@@ -109,16 +190,26 @@ func (m model) renderExpenseRow(expense types.Expense, widths expenseColumnWidth
 	if isSelected {
 		bgColor = m.styles.Theme.Primary
 		fgColor = lipgloss.Color("#0F1117")
+	} else if isMarked {
+		bgColor = m.styles.Theme.Background
+		fgColor = m.styles.Theme.Selected
 	} else {
 		bgColor = m.styles.Theme.Background
 		fgColor = m.styles.Theme.Foreground
 	}
 	baseStyle := lipgloss.NewStyle().Foreground(fgColor).Background(bgColor)
-	if isSelected {
+	if isSelected || isMarked {
 		baseStyle = baseStyle.Bold(true)
 	}
 
-	datePart := baseStyle.Width(widths.Date).Align(lipgloss.Left).Render(expense.Date.Format("2006-01-02 15:04:05"))
+	dateText := expense.Date.Format("2006-01-02 15:04:05")
+	if m.isCompact() {
+		dateText = expense.Date.Format("2006-01-02")
+	}
+	if isMarked {
+		dateText += " ●"
+	}
+	datePart := baseStyle.Width(widths.Date).Align(lipgloss.Left).Render(dateText)
 	descPart := baseStyle.Width(widths.Desc).Align(lipgloss.Left).Render(desc)
 	categoryColor := CategoryColor(categoryText)
 	if isSelected {
@@ -128,5 +219,8 @@ func (m model) renderExpenseRow(expense types.Expense, widths expenseColumnWidth
 	categoryPart := categoryStyle.Render(categoryText)
 	amountPart := baseStyle.Width(widths.Amount).Align(lipgloss.Right).Render(formattedAmount)
 	spacing := baseStyle.Render("  ")
+	if widths.Category == 0 {
+		return datePart + spacing + descPart + spacing + amountPart
+	}
 	return datePart + spacing + descPart + spacing + categoryPart + spacing + amountPart
 }
@@ -2,12 +2,31 @@ package program
 
 import (
 	"fmt"
+	"image/color"
+	"math"
 	"strings"
 	"time"
 
+	"charm.land/lipgloss/v2"
 	"github.com/kyawphyothu/sana/types"
 )
 
+// reportView is how the monthly report box shows the monthly totals.
+type reportView int
+
+const (
+	reportTable reportView = iota
+	reportHorizontalBars
+	reportVerticalBars
+	numReportViews
+)
+
+// Bar characters in eighths: horizontal bars grow left to right, vertical bars bottom to top
+var (
+	horizontalBarEighths = []rune("▏▎▍▌▋▊▉█")
+	verticalBarEighths   = []rune("▁▂▃▄▅▆▇█")
+)
+
 // monthlyReportColumnWidths holds column widths for the monthly report table
 type monthlyReportColumnWidths struct {
 	Month  int
@@ -16,15 +35,19 @@ type monthlyReportColumnWidths struct {
 
 // renderMonthlyReportBox creates the monthly report section (third box)
 func (m model) renderMonthlyReportBox() string {
-	expensesHeight := m.calculateExpensesBoxHeight()
-	boxHeight := m.ui.height - titleBoxHeight - expensesHeight
+	boxHeight := m.calculateLowerBoxHeight()
 
 	boxWidth := m.ui.width - (m.ui.width / 2)
+	if m.isCompact() {
+		boxWidth = m.ui.width
+	}
 
 	var content strings.Builder
 
 	if len(m.data.monthlyReport) == 0 {
 		content.WriteString(m.styles.Muted.Render("No monthly report data"))
+	} else if m.ui.reportView == reportVerticalBars {
+		content.WriteString(m.renderMonthlyReportColumns(boxWidth-tableBorderPadding, boxHeight-2))
 	} else {
 		tableWidth := boxWidth - tableBorderPadding
 		maxRows := boxHeight - monthlyReportBoxHeaderRows
@@ -44,6 +67,12 @@ func (m model) renderMonthlyReportBox() string {
 		renderRow := func(globalRowIndex int, isSelected bool) string {
 			return m.renderMonthlyReportRow(m.data.monthlyReport[globalRowIndex], widths, isSelected)
 		}
+		if m.ui.reportView == reportHorizontalBars {
+			config.Header = m.buildMonthlyReportChartHeader(tableWidth)
+			renderRow = func(globalRowIndex int, isSelected bool) string {
+				return m.renderMonthlyReportBarRow(m.data.monthlyReport[globalRowIndex], tableWidth, isSelected)
+			}
+		}
 		content.WriteString(m.renderTableBody(config, renderRow))
 	}
 
@@ -88,6 +117,140 @@ func (m model) renderMonthlyReportRow(report types.MonthlyReport, widths monthly
 	return starPart + m.styles.Line.Render(line)
 }
 
+// monthlyReportStats returns the highest and the average monthly total
+func (m model) monthlyReportStats() (highest, average float64) {
+	for _, r := range m.data.monthlyReport {
+		highest = max(highest, r.Total)
+		average += r.Total
+	}
+	if len(m.data.monthlyReport) > 0 {
+		average /= float64(len(m.data.monthlyReport))
+	}
+	return highest, average
+}
+
+// barColor colors a month's bar by whether its total is above the average
+func (m model) barColor(total, average float64) color.Color {
+	if total > average {
+		return m.styles.Theme.Error
+	}
+	return m.styles.Theme.Success
+}
+
+// buildMonthlyReportChartHeader returns the chart header (average and legend) and separator
+func (m model) buildMonthlyReportChartHeader(tableWidth int) string {
+	_, average := m.monthlyReportStats()
+	legend := m.styles.Header.Render("Avg "+formatAmountWithCommas(average)) +
+		m.styles.Line.Render("  ") +
+		m.styles.Line.Foreground(m.styles.Theme.Error).Render("█") + m.styles.Muted.Render(" over  ") +
+		m.styles.Line.Foreground(m.styles.Theme.Success).Render("█") + m.styles.Muted.Render(" under")
+	separator := strings.Repeat("─", tableWidth)
+	return legend + "\n" + m.styles.Muted.Render(separator) + "\n"
+}
+
+// monthLabelStyle highlights the selected month and the active month in the charts
+func (m model) monthLabelStyle(month time.Time, isSelected bool) lipgloss.Style {
+	switch {
+	case isSelected:
+		return m.styles.Selected
+	case sameMonth(month, m.ui.activeMonth):
+		return m.styles.Line.Foreground(m.styles.Theme.Success).Bold(true)
+	}
+	return m.styles.Line
+}
+
+// renderMonthlyReportBarRow renders one month as a horizontal bar scaled to the box width
+func (m model) renderMonthlyReportBarRow(report types.MonthlyReport, tableWidth int, isSelected bool) string {
+	highest, average := m.monthlyReportStats()
+	starPart := m.styles.Line.Render(" ")
+	if sameMonth(report.Month, m.ui.activeMonth) {
+		starPart = m.styles.Line.Foreground(m.styles.Theme.Success).Render("*")
+	}
+	label := m.monthLabelStyle(report.Month, isSelected).Render(report.Month.Format("2006-01"))
+	// Line the amounts up on the widest one
+	amountWidth := 0
+	for _, r := range m.data.monthlyReport {
+		amountWidth = max(amountWidth, len(formatAmountWithCommas(r.Total)))
+	}
+	amount := fmt.Sprintf("%*s", amountWidth, formatAmountWithCommas(report.Total))
+	// star + month + space ... space + amount
+	barWidth := tableWidth - 1 - 7 - 2 - amountWidth
+	bar := horizontalBar(report.Total, highest, barWidth)
+	barPart := m.styles.Line.Foreground(m.barColor(report.Total, average)).Width(barWidth).Render(bar)
+	return starPart + label + m.styles.Line.Render(" ") + barPart + m.styles.Line.Render(" ") + m.styles.Line.Render(amount)
+}
+
+// horizontalBar returns a bar of up to width cells for value out of highest, in eighths of a cell
+func horizontalBar(value, highest float64, width int) string {
+	if highest <= 0 || width <= 0 || value <= 0 {
+		return ""
+	}
+	eighths := int(math.Round(value / highest * float64(width*8)))
+	bar := strings.Repeat("█", eighths/8)
+	if rem := eighths % 8; rem > 0 {
+		bar += string(horizontalBarEighths[rem-1])
+	}
+	return bar
+}
+
+// verticalBarCell returns the cell at level (0 = bottom row) of a bar that is eighths/8 rows tall
+func verticalBarCell(eighths, level int) string {
+	fill := min(eighths-level*8, 8)
+	if fill <= 0 {
+		return " "
+	}
+	return string(verticalBarEighths[fill-1])
+}
+
+// renderMonthlyReportColumns renders the monthly totals as vertical bars, oldest month on the left,
+// keeping the selected month in view. height is the content height inside the border.
+func (m model) renderMonthlyReportColumns(tableWidth, height int) string {
+	highest, average := m.monthlyReportStats()
+	months := len(m.data.monthlyReport)
+	chartRows := max(height-3, 1) // header + separator + labels
+	selected := months - 1 - m.ui.monthlyReportList.SelectedRow()
+	start, end := m.monthlyReportColumnRange(tableWidth)
+
+	var b strings.Builder
+	b.WriteString(m.buildMonthlyReportChartHeader(tableWidth))
+	for row := chartRows - 1; row >= 0; row-- {
+		var line strings.Builder
+		for i := start; i < end; i++ {
+			report := m.data.monthlyReport[months-1-i]
+			eighths := 0
+			if highest > 0 {
+				eighths = int(math.Round(report.Total / highest * float64(chartRows*8)))
+			}
+			cell := verticalBarCell(eighths, row)
+			line.WriteString(m.styles.Line.Foreground(m.barColor(report.Total, average)).Render(cell + cell))
+			line.WriteString(m.styles.Line.Render(" "))
+		}
+		b.WriteString(line.String())
+		b.WriteString("\n")
+	}
+	for i := start; i < end; i++ {
+		report := m.data.monthlyReport[months-1-i]
+		isSelected := m.isSelected(monthlyReportBox) && i == selected
+		b.WriteString(m.monthLabelStyle(report.Month, isSelected).Render(report.Month.Format("01")))
+		b.WriteString(m.styles.Line.Render(" "))
+	}
+	return b.String()
+}
+
+// monthlyReportColumnRange returns the months shown as columns of the vertical bar
+// chart, counted oldest first: the newest ones that fit, scrolled back to the selection.
+func (m model) monthlyReportColumnRange(tableWidth int) (start, end int) {
+	// The report is newest first; columns run oldest to newest
+	months := len(m.data.monthlyReport)
+	visible := max(tableWidth/monthlyReportColumnWidth, 1)
+	selected := months - 1 - m.ui.monthlyReportList.SelectedRow()
+	start = max(months-visible, 0)
+	if selected < start {
+		start = selected
+	}
+	return start, min(start+visible, months)
+}
+
 // calculateMonthlyReportColumnWidths computes column widths for the monthly report table
 func (m model) calculateMonthlyReportColumnWidths(tableWidth int) monthlyReportColumnWidths {
 	starWidth := 1
@@ -100,3 +263,8 @@ func (m model) calculateMonthlyReportColumnWidths(tableWidth int) monthlyReportC
 func sameMonth(a, b time.Time) bool {
 	return a.Month() == b.Month() && a.Year() == b.Year()
 }
+
+// startOfMonth returns midnight on the first day of t's month, in local time.
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+}
@@ -7,19 +7,25 @@ import (
 	"github.com/kyawphyothu/sana/types"
 )
 
-// summaryColumnWidths holds column widths for the summary table
+// summaryColumnWidths holds column widths for the summary table.
+// Bar is 0 when the box is too narrow for the share bars, and Count is 0
+// when it is too narrow even for every category name.
 type summaryColumnWidths struct {
 	Category int
+	Bar      int
+	Percent  int
 	Count    int
 	Amount   int
 }
 
 // renderSummaryBox creates the summary section grouped by category (third box)
 func (m model) renderSummaryBox() string {
-	expensesHeight := m.calculateExpensesBoxHeight()
-	boxHeight := m.ui.height - titleBoxHeight - expensesHeight
+	boxHeight := m.calculateLowerBoxHeight()
 
 	boxWidth := m.ui.width / 2
+	if m.isCompact() {
+		boxWidth = m.ui.width
+	}
 
 	var content strings.Builder
 
@@ -28,6 +34,11 @@ func (m model) renderSummaryBox() string {
 	} else {
 		tableWidth := boxWidth - tableBorderPadding
 		maxRows := boxHeight - summaryBoxHeaderRows
+		header := m.buildSummaryTableHeader(tableWidth)
+		if m.ui.summaryStackedBar {
+			header = m.renderStackedShareBar(tableWidth) + "\n" + header
+			maxRows--
+		}
 		if maxRows < 1 {
 			maxRows = 1
 		}
@@ -35,7 +46,7 @@ func (m model) renderSummaryBox() string {
 		footer := m.styles.Header.Render(m.renderSummaryTotalLine(widths, m.data.total))
 		config := TableConfig{
 			TableWidth:       tableWidth,
-			Header:           m.buildSummaryTableHeader(tableWidth),
+			Header:           header,
 			MaxRows:          maxRows,
 			TotalRows:        len(m.data.summary),
 			ScrollOffset:     m.ui.summaryList.ScrollOffset(),
@@ -68,36 +79,134 @@ func (m model) renderSummaryBox() string {
 // buildSummaryTableHeader returns the table header and separator for the summary box
 func (m model) buildSummaryTableHeader(tableWidth int) string {
 	widths := m.calculateSummaryColumnWidths(tableWidth)
-	header := fmt.Sprintf("%-*s  %*s  %*s", widths.Category, "Category", widths.Count, "Count", widths.Amount, "Amount")
+	header := fmt.Sprintf("%-*s  ", widths.Category, "Category")
+	if widths.Bar > 0 {
+		header += fmt.Sprintf("%-*s  ", widths.Bar, "")
+	}
+	header += fmt.Sprintf("%*s  ", widths.Percent, "%")
+	if widths.Count > 0 {
+		header += fmt.Sprintf("%*s  ", widths.Count, "Count")
+	}
+	header += fmt.Sprintf("%*s", widths.Amount, "Amount")
 	separator := strings.Repeat("─", tableWidth)
 	return m.styles.Header.Render(header) + "\n" + m.styles.Muted.Render(separator) + "\n"
 }
 
-// calculateSummaryColumnWidths computes column widths for the summary table
+// calculateSummaryColumnWidths computes column widths for the summary table.
+// The amount column fits the largest amount; space left after the longest
+// category name goes to the share bars.
 func (m model) calculateSummaryColumnWidths(tableWidth int) summaryColumnWidths {
-	amountWidth := tableAmountWidthSummary
+	amountWidth := max(len("Amount"), len(formatAmountWithCommas(m.data.total)))
 	countWidth := tableCountWidth
+	percentWidth := tablePercentWidth
 	spacing := tableColumnSpacing
 	totalSpacing := spacing * tableColumnGapsSummary
-	categoryWidth := tableWidth - amountWidth - countWidth - totalSpacing
+	categoryWidth := tableWidth - amountWidth - countWidth - percentWidth - totalSpacing
+	if categoryWidth < tableCategoryWidth {
+		// Counts are in the category overlay; names must fit
+		countWidth = 0
+		categoryWidth += tableCountWidth + spacing
+	}
 	if categoryWidth < tableMinCategoryWidth {
 		categoryWidth = tableMinCategoryWidth
 	}
-	return summaryColumnWidths{Category: categoryWidth, Count: countWidth, Amount: amountWidth}
+	widths := summaryColumnWidths{Category: categoryWidth, Percent: percentWidth, Count: countWidth, Amount: amountWidth}
+	if bar := categoryWidth - tableCategoryWidth - spacing; bar >= tableMinShareBarWidth {
+		widths.Category = tableCategoryWidth
+		widths.Bar = bar
+	}
+	return widths
 }
 
-// renderSummaryRow renders a single summary row (category, count, amount)
+// categoryShare returns part as a fraction of total (0 when total is 0)
+func categoryShare(part, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return part / total
+}
+
+// renderSummaryRow renders a single summary row (category, share bar, percent, count, amount).
+// Bars are scaled to the largest category so small differences stay visible.
 func (m model) renderSummaryRow(cat types.CategorySummary, widths summaryColumnWidths, isSelected bool) string {
 	formattedAmount := formatAmountWithCommas(cat.Total)
-	line := fmt.Sprintf("%-*s  %*d  %*s", widths.Category, cat.Category, widths.Count, cat.Count, widths.Amount, formattedAmount)
+	share := categoryShare(cat.Total, m.data.total)
+	var largest float64
+	for _, c := range m.data.summary {
+		largest = max(largest, c.Total)
+	}
+	style := m.styles.Line
+	barColor := CategoryColor(cat.Category)
 	if isSelected {
-		return m.styles.Selected.Render(line)
+		style = m.styles.Selected
+		barColor = CategoryColorSelected(cat.Category)
+	}
+
+	row := style.Render(fmt.Sprintf("%-*s  ", widths.Category, cat.Category))
+	if widths.Bar > 0 {
+		bar := horizontalBar(cat.Total, largest, widths.Bar)
+		row += style.Foreground(barColor).Width(widths.Bar).Render(bar) + style.Render("  ")
+	}
+	row += style.Render(fmt.Sprintf("%*.1f%%  ", widths.Percent-1, share*100))
+	if widths.Count > 0 {
+		row += style.Render(fmt.Sprintf("%*d  ", widths.Count, cat.Count))
 	}
-	return m.styles.Line.Render(line)
+	return row + style.Render(fmt.Sprintf("%*s", widths.Amount, formattedAmount))
 }
 
 // renderSummaryTotalLine renders the "Total" row at the bottom of the summary table
 func (m model) renderSummaryTotalLine(widths summaryColumnWidths, total float64) string {
 	formattedTotal := formatAmountWithCommas(total)
-	return fmt.Sprintf("%-*s  %*s  %*s", widths.Category, "Total", widths.Count, "", widths.Amount, formattedTotal)
+	line := fmt.Sprintf("%-*s  ", widths.Category, "Total")
+	if widths.Bar > 0 {
+		line += fmt.Sprintf("%-*s  ", widths.Bar, "")
+	}
+	line += fmt.Sprintf("%*s  ", widths.Percent, "")
+	if widths.Count > 0 {
+		line += fmt.Sprintf("%*s  ", widths.Count, "")
+	}
+	return line + fmt.Sprintf("%*s", widths.Amount, formattedTotal)
+}
+
+// renderStackedShareBar renders the month's split by category as one bar across the table.
+// Cells are shared out by largest remainder so the segments always fill the width.
+func (m model) renderStackedShareBar(tableWidth int) string {
+	cells := stackedShareCells(m.data.summary, m.data.total, tableWidth)
+	var b strings.Builder
+	for i, cat := range m.data.summary {
+		if cells[i] > 0 {
+			b.WriteString(m.styles.Line.Foreground(CategoryColor(cat.Category)).Render(strings.Repeat("█", cells[i])))
+		}
+	}
+	if b.Len() == 0 {
+		return m.styles.Muted.Render(strings.Repeat("─", tableWidth))
+	}
+	return b.String()
+}
+
+// stackedShareCells splits width cells between the categories in proportion to their totals.
+func stackedShareCells(summary []types.CategorySummary, total float64, width int) []int {
+	cells := make([]int, len(summary))
+	if total <= 0 || width <= 0 {
+		return cells
+	}
+	remainders := make([]float64, len(summary))
+	used := 0
+	for i, cat := range summary {
+		exact := categoryShare(cat.Total, total) * float64(width)
+		cells[i] = int(exact)
+		remainders[i] = exact - float64(cells[i])
+		used += cells[i]
+	}
+	for ; used < width; used++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		cells[best]++
+		remainders[best] = -1
+	}
+	return cells
 }
@@ -5,6 +5,10 @@ import (
 	"testing"
 	"time"
 
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kyawphyothu/sana/database"
 	"github.com/kyawphyothu/sana/types"
 )
 
@@ -94,7 +98,7 @@ func TestRenderOverlayExpenseRow(t *testing.T) {
 		Description: "Lunch",
 		Type:        types.ExpenseTypeFood,
 	}
-	got := m.renderOverlayExpenseRow(exp, widths)
+	got := m.renderOverlayExpenseRow(exp, widths, false)
 	if got == "" {
 		t.Fatal("renderOverlayExpenseRow returned empty string")
 	}
@@ -108,3 +112,173 @@ func TestRenderOverlayExpenseRow(t *testing.T) {
 		t.Error("output should contain description")
 	}
 }
+
+func TestHorizontalBar(t *testing.T) {
+	tests := []struct {
+		value, highest float64
+		width          int
+		want           string
+	}{
+		{100, 100, 4, "████"},
+		{50, 100, 4, "██"},
+		{10, 100, 4, "▍"},
+		{0, 100, 4, ""},
+		{10, 0, 4, ""},
+	}
+	for _, tt := range tests {
+		if got := horizontalBar(tt.value, tt.highest, tt.width); got != tt.want {
+			t.Errorf("horizontalBar(%v, %v, %d): got %q, want %q", tt.value, tt.highest, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestVerticalBarCell(t *testing.T) {
+	// A bar 1.5 rows tall: full bottom cell, half cell above, empty above that
+	for level, want := range []string{"█", "▄", " "} {
+		if got := verticalBarCell(12, level); got != want {
+			t.Errorf("verticalBarCell(12, %d): got %q, want %q", level, got, want)
+		}
+	}
+}
+
+func TestMonthlyReportChartToggle(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.width, m.ui.height = 100, 30
+	m.ui.selected = monthlyReportBox
+	m.data.monthlyReport = []types.MonthlyReport{
+		{Month: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Total: 300},
+		{Month: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Total: 100},
+	}
+	for _, want := range []reportView{reportHorizontalBars, reportVerticalBars, reportTable} {
+		updated, _ := m.Update(tea.KeyPressMsg{Code: 'b', Text: "b"})
+		m = updated.(model)
+		if m.ui.reportView != want {
+			t.Fatalf("b: reportView = %v, want %v", m.ui.reportView, want)
+		}
+		if view := m.renderMonthlyReportBox(); !strings.Contains(view, "03") {
+			t.Errorf("reportView %v: box does not show March:\n%s", want, view)
+		}
+	}
+
+	// In the vertical chart h moves to the older month
+	m.ui.reportView = reportVerticalBars
+	updated, _ := m.Update(tea.KeyPressMsg{Code: 'h', Text: "h"})
+	m = updated.(model)
+	if m.ui.monthlyReportList.SelectedRow() != 1 {
+		t.Errorf("h: selected row %d, want 1 (February)", m.ui.monthlyReportList.SelectedRow())
+	}
+}
+
+func TestStackedShareCells(t *testing.T) {
+	summary := []types.CategorySummary{{Total: 50}, {Total: 30}, {Total: 20}}
+	tests := []struct {
+		width int
+		want  []int
+	}{
+		{10, []int{5, 3, 2}},
+		{7, []int{4, 2, 1}}, // 3.5, 2.1, 1.4: the leftover cell goes to the largest remainder
+		{0, []int{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		got := stackedShareCells(summary, 100, tt.width)
+		sum := 0
+		for i := range got {
+			sum += got[i]
+			if got[i] != tt.want[i] {
+				t.Errorf("stackedShareCells(width %d): got %v, want %v", tt.width, got, tt.want)
+				break
+			}
+		}
+		if sum != tt.width {
+			t.Errorf("stackedShareCells(width %d): cells sum to %d", tt.width, sum)
+		}
+	}
+}
+
+func TestSummaryColumnWidths(t *testing.T) {
+	m := minimalModelWithStyles()
+	m.data.total = 1234.5
+	wide := m.calculateSummaryColumnWidths(60)
+	if wide.Bar < tableMinShareBarWidth || wide.Count == 0 || wide.Amount != len("1,234.50") {
+		t.Errorf("calculateSummaryColumnWidths(60): got %+v, want bar, count and an amount column fitting 1,234.50", wide)
+	}
+	narrow := m.calculateSummaryColumnWidths(31)
+	if narrow.Bar != 0 || narrow.Count != 0 || narrow.Category < len("Entertainment") {
+		t.Errorf("calculateSummaryColumnWidths(31): got %+v, want no bar or count and room for every category", narrow)
+	}
+
+	m.data.summary = []types.CategorySummary{{Category: "Food", Count: 2, Total: 1234.5}}
+	row := m.renderSummaryRow(m.data.summary[0], wide, false)
+	if !strings.Contains(row, "100.0%") || !strings.Contains(row, "█") {
+		t.Errorf("renderSummaryRow: got %q, want a 100.0%% share and a bar", row)
+	}
+}
+
+func TestCompactLayout(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	m.ui.width, m.ui.height = compactMinWidth, compactMinHeight
+	m.ui.activeMonth = time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	expense := types.Expense{ID: 1, Date: time.Date(2025, 3, 15, 9, 30, 0, 0, time.Local), Amount: 12.5, Description: "lunch", Type: types.ExpenseTypeFood}
+	m.data.expenses = []types.Expense{expense}
+	m.data.allExpenses = m.data.expenses
+	if !m.isCompact() {
+		t.Fatalf("isCompact at %dx%d: got false, want true", m.ui.width, m.ui.height)
+	}
+
+	// One box filling the screen under a one-line tab bar, without the time or category columns
+	if bar := m.renderTitleBox(); lipgloss.Height(bar) != 1 || !strings.Contains(bar, "Mar 2025") || strings.Contains(bar, "░") {
+		t.Errorf("renderTitleBox (compact): got %q, want a tab bar with the month and no figlet", bar)
+	}
+	box := m.renderExpensesBox()
+	if lipgloss.Height(box) != m.ui.height-compactTabBarHeight-statusBarHeight {
+		t.Errorf("renderExpensesBox (compact): got height %d, want %d", lipgloss.Height(box), m.ui.height-compactTabBarHeight-statusBarHeight)
+	}
+	for _, line := range strings.Split(box, "\n") {
+		if lipgloss.Width(line) != m.ui.width {
+			t.Fatalf("renderExpensesBox (compact): line %q is %d wide, want %d", line, lipgloss.Width(line), m.ui.width)
+		}
+	}
+	if !strings.Contains(box, "2025-03-15") || strings.Contains(box, "09:30") || strings.Contains(box, "Food") {
+		t.Errorf("renderExpensesBox (compact): want the date without time and no category column:\n%s", box)
+	}
+
+	// tab switches boxes like tabs; an overlay keeps its box underneath
+	for _, want := range []selectedBox{summaryBox, monthlyReportBox, expensesBox} {
+		updated, _ := m.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+		m = updated.(model)
+		if m.ui.selected != want || m.compactBox() != want {
+			t.Errorf("tab: selected %v (showing %v), want %v", m.ui.selected, m.compactBox(), want)
+		}
+	}
+	updated, _ := m.Update(tea.KeyPressMsg{Code: 'C', Text: "C"})
+	m = updated.(model)
+	if m.compactBox() != expensesBox {
+		t.Errorf("calendar from expenses: showing %v underneath, want expenses box", m.compactBox())
+	}
+
+	m.ui.width, m.ui.height = minWidth, minHeight
+	if m.isCompact() {
+		t.Errorf("isCompact at %dx%d: got true, want false", minWidth, minHeight)
+	}
+}
+
+func TestRenderAmountPreview(t *testing.T) {
+	m := InitialModel(database.NewMemoryStore(), "default")
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"", ""},
+		{"12.50", ""},
+		{"45.60/3", "= 15.20"},
+		{"1000*2+0.5", "= 2,000.50"},
+		{"12+", "✗ expected a number at column 4"},
+		{"abc", "✗ unexpected 'a' at column 1"},
+	}
+	for _, tt := range tests {
+		m.form.amount.SetValue(tt.amount)
+		if got := ansi.Strip(m.renderAmountPreview()); got != tt.want {
+			t.Errorf("renderAmountPreview(%q): got %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
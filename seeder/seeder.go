@@ -87,7 +87,7 @@ func main() {
 		totalExpenses = 200
 	)
 
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfig("")
 	if err != nil {
 		fmt.Println("Error loading config:", err)
 		os.Exit(1)
@@ -86,6 +86,7 @@ func (e ExpenseType) String() string {
 // Expense is the main expense model for the app.
 type Expense struct {
 	ID          int64
+	UUID        string // stable across devices; ID is local to one database
 	Date        time.Time
 	Amount      float64
 	Description string
@@ -106,3 +107,19 @@ type MonthlyReport struct {
 	Month time.Time
 	Total float64
 }
+
+// DescriptionHistory is a description used before, with the category it is usually
+// filed under and the amount it was last spent
+type DescriptionHistory struct {
+	Description string // as written the last time
+	Type        ExpenseType
+	Amount      float64
+	Count       int
+}
+
+// DailyTotal represents aggregated expense data for one day (local midnight)
+type DailyTotal struct {
+	Day   time.Time
+	Total float64
+	Count int
+}